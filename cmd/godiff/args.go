@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+)
+
+// labels collects the values of repeated --label flags.
+type labels []string
+
+func (l *labels) String() string { return strings.Join(*l, ",") }
+
+func (l *labels) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// expandShortFlags splits grouped single-letter flags, so that "-ur"
+// works like "-u -r" and "-U1" like "-U 1", as in GNU diff. Letters in
+// bools are boolean flags; a letter in values takes the rest of the group
+// as its value, or the next argument when it ends the group. Groups with
+// other letters are kept as they are.
+func expandShortFlags(args []string, bools, values string) []string {
+	var ret []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(ret, args[i:]...)
+		}
+		if len(arg) <= 2 || arg[0] != '-' || arg[1] == '-' {
+			ret = append(ret, arg)
+			continue
+		}
+		if split, ok := splitShortFlags(arg[1:], bools, values); ok {
+			ret = append(ret, split...)
+		} else {
+			ret = append(ret, arg)
+		}
+	}
+	return ret
+}
+
+func splitShortFlags(letters, bools, values string) ([]string, bool) {
+	var ret []string
+	for i, c := range letters {
+		flag := "-" + string(c)
+		switch {
+		case strings.ContainsRune(bools, c):
+			ret = append(ret, flag)
+		case strings.ContainsRune(values, c):
+			ret = append(ret, flag)
+			if v := letters[i+1:]; v != "" {
+				ret = append(ret, v)
+			}
+			return ret, true
+		default:
+			return nil, false
+		}
+	}
+	return ret, true
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"shanhu.io/third/diff"
+	"shanhu.io/third/diffmp"
+)
+
+// writeCharDiff writes a character-level diff computed by the diffmp
+// engine. Deleted text is wrapped in [-...-] and inserted text in {+...+},
+// or colored when colors is not nil.
+func writeCharDiff(w io.Writer, a, b *diff.File, colors *diff.Colors) error {
+	// Diff the decoded text; Content is in the original encoding.
+	text1 := strings.Join(a.Lines, "")
+	text2 := strings.Join(b.Lines, "")
+	dmp := diffmp.New()
+	diffs := dmp.DiffMain(text1, text2, true)
+	diffs = diffmp.DiffCleanupSemantic(diffs)
+
+	buf := new(bytes.Buffer)
+	header := ""
+	if colors != nil {
+		header = colors.Header
+	}
+	fmt.Fprintf(buf, "%s--- %s%s\n", header, a.Name, reset(header))
+	fmt.Fprintf(buf, "%s+++ %s%s\n", header, b.Name, reset(header))
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmp.Noop:
+			buf.WriteString(d.Text)
+		case diffmp.Delete:
+			if colors != nil {
+				buf.WriteString(colors.Delete + d.Text + reset(colors.Delete))
+			} else {
+				buf.WriteString("[-" + d.Text + "-]")
+			}
+		case diffmp.Insert:
+			if colors != nil {
+				buf.WriteString(colors.Insert + d.Text + reset(colors.Insert))
+			} else {
+				buf.WriteString("{+" + d.Text + "+}")
+			}
+		}
+	}
+	if bs := buf.Bytes(); bs[len(bs)-1] != '\n' {
+		buf.WriteString("\n")
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func reset(color string) string {
+	if color == "" {
+		return ""
+	}
+	return "\x1b[m"
+}
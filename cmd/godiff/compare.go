@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"shanhu.io/third/diff"
+)
+
+// Output formats.
+const (
+	formatNormal = iota
+	formatUnified
+	formatContext
+	formatRCS
+	formatSideBySide
+	formatHTML
+	formatJSON
+)
+
+type options struct {
+	format      int
+	context     int
+	width       int
+	recursive   bool
+	newFile     bool
+	brief       bool
+	chars       bool
+	intraline   bool
+	key         func(string) string
+	ignoreBlank bool
+	normalize   bool
+	labels      []string
+	colors      *diff.Colors
+	cmdLine     string // command line printed before each file diff in dirs
+}
+
+// status is the exit status of the program.
+type status int
+
+const (
+	same    status = 0
+	differ  status = 1
+	trouble status = 2
+)
+
+func worse(s1, s2 status) status {
+	if s1 > s2 {
+		return s1
+	}
+	return s2
+}
+
+type comparer struct {
+	opts *options
+	out  io.Writer
+	errs io.Writer
+}
+
+func (c *comparer) fail(err error) status {
+	fmt.Fprintf(c.errs, "godiff: %s\n", err)
+	return trouble
+}
+
+func formatTime(t time.Time, format int) string {
+	if format == formatContext {
+		return t.Format("Mon Jan _2 15:04:05 2006")
+	}
+	return t.Format("2006-01-02 15:04:05.000000000 -0700")
+}
+
+// loadFile loads a file; an empty file is returned for a missing file when
+// absent is true. Path "-" reads the standard input.
+func (c *comparer) loadFile(path string, absent bool) (*diff.File, error) {
+	opts := &diff.ReadOptions{
+		NormalizeEOL: c.opts.normalize,
+		ModTime:      true,
+	}
+	var f *diff.File
+	var err error
+	if absent {
+		f, err = diff.ReadFile(path, new(emptyReader), opts)
+		if err == nil {
+			t := time.Unix(0, 0)
+			f.Time = &t
+		}
+	} else if path == "-" {
+		f, err = diff.ReadFile(path, os.Stdin, opts)
+		if f != nil && f.Time == nil {
+			t := time.Now()
+			f.Time = &t
+		}
+	} else {
+		f, err = diff.LoadFile(path, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if f.Time != nil {
+		f.TimeStr = formatTime(*f.Time, c.opts.format)
+		f.Time = nil
+	}
+	return f, nil
+}
+
+type emptyReader struct{}
+
+func (r *emptyReader) Read([]byte) (int, error) { return 0, io.EOF }
+
+func (c *comparer) applyLabels(a, b *diff.File) {
+	if len(c.opts.labels) > 0 {
+		a.Name, a.TimeStr = c.opts.labels[0], ""
+	}
+	if len(c.opts.labels) > 1 {
+		b.Name, b.TimeStr = c.opts.labels[1], ""
+	}
+}
+
+// compareFiles compares two regular files. When header is set, it is
+// printed before the diff if the files differ.
+func (c *comparer) compareFiles(
+	pathA, pathB string, absentA, absentB bool, header string,
+) status {
+	a, err := c.loadFile(pathA, absentA)
+	if err != nil {
+		return c.fail(err)
+	}
+	b, err := c.loadFile(pathB, absentB)
+	if err != nil {
+		return c.fail(err)
+	}
+	c.applyLabels(a, b)
+
+	in := &diff.Input{
+		A:                a,
+		B:                b,
+		Context:          c.opts.context,
+		Key:              c.opts.key,
+		IgnoreBlankLines: c.opts.ignoreBlank,
+		Width:            c.opts.width,
+		Colors:           c.opts.colors,
+		Intraline:        c.opts.intraline,
+	}
+	if !diff.Differs(in) {
+		if c.opts.format == formatSideBySide && !c.opts.brief {
+			if err := diff.WriteSideBySideDiff(c.out, in); err != nil {
+				return c.fail(err)
+			}
+		}
+		return same
+	}
+	if c.opts.brief {
+		fmt.Fprintf(c.out, "Files %s and %s differ\n", pathA, pathB)
+		return differ
+	}
+	if header != "" && c.opts.format != formatHTML &&
+		c.opts.format != formatJSON {
+		fmt.Fprintln(c.out, header)
+	}
+
+	if c.opts.chars && !a.Binary && !b.Binary {
+		err = writeCharDiff(c.out, a, b, c.opts.colors)
+	} else {
+		err = writeDiff(c.out, in, c.opts.format)
+	}
+	if err != nil {
+		return c.fail(err)
+	}
+	return differ
+}
+
+func writeDiff(w io.Writer, in *diff.Input, format int) error {
+	switch format {
+	case formatUnified:
+		return diff.WriteUnifiedDiff(w, in)
+	case formatContext:
+		return diff.WriteContextDiff(w, in)
+	case formatRCS:
+		return diff.WriteRCSDiff(w, in)
+	case formatSideBySide:
+		return diff.WriteSideBySideDiff(w, in)
+	case formatHTML:
+		return diff.WriteHTMLDiff(w, in)
+	case formatJSON:
+		return diff.WriteJSONDiff(w, in)
+	}
+	return diff.WriteNormalDiff(w, in)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func readDirNames(dir string) (map[string]os.FileInfo, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[string]os.FileInfo)
+	for _, info := range infos {
+		ret[info.Name()] = info
+	}
+	return ret, nil
+}
+
+// compareDirs compares two directories entry by entry.
+func (c *comparer) compareDirs(dirA, dirB string) status {
+	entriesA, err := readDirNames(dirA)
+	if err != nil {
+		return c.fail(err)
+	}
+	entriesB, err := readDirNames(dirB)
+	if err != nil {
+		return c.fail(err)
+	}
+	var names []string
+	for name := range entriesA {
+		names = append(names, name)
+	}
+	for name := range entriesB {
+		if _, found := entriesA[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	ret := same
+	for _, name := range names {
+		infoA, inA := entriesA[name]
+		infoB, inB := entriesB[name]
+		ret = worse(ret, c.compareEntry(
+			filepath.Join(dirA, name), filepath.Join(dirB, name),
+			infoA, infoB, inA, inB,
+		))
+	}
+	return ret
+}
+
+// compareEntry compares two entries of directories being compared. The
+// entry might be missing on one side.
+func (c *comparer) compareEntry(
+	pathA, pathB string, infoA, infoB os.FileInfo, inA, inB bool,
+) status {
+	header := c.opts.cmdLine + " " + pathA + " " + pathB
+	if !inA || !inB {
+		path, info := pathA, infoA
+		if !inA {
+			path, info = pathB, infoB
+		}
+		if c.opts.newFile && info.Mode().IsRegular() {
+			return c.compareFiles(pathA, pathB, !inA, !inB, header)
+		}
+		if c.opts.newFile && info.IsDir() && c.opts.recursive {
+			return c.compareNewDir(pathA, pathB, !inA)
+		}
+		dir, name := filepath.Split(path)
+		fmt.Fprintf(c.out, "Only in %s: %s\n", filepath.Clean(dir), name)
+		return differ
+	}
+
+	dirA, dirB := infoA.IsDir(), infoB.IsDir()
+	switch {
+	case dirA && dirB:
+		if c.opts.recursive {
+			return c.compareDirs(pathA, pathB)
+		}
+		fmt.Fprintf(
+			c.out, "Common subdirectories: %s and %s\n", pathA, pathB,
+		)
+		return same
+	case dirA != dirB:
+		kindA, kindB := "regular file", "regular file"
+		if dirA {
+			kindA = "directory"
+		} else {
+			kindB = "directory"
+		}
+		fmt.Fprintf(
+			c.out, "File %s is a %s while file %s is a %s\n",
+			pathA, kindA, pathB, kindB,
+		)
+		return differ
+	}
+	return c.compareFiles(pathA, pathB, false, false, header)
+}
+
+// compareNewDir compares a directory that only exists on one side with
+// nothing, as if all of its files are empty on the other side.
+func (c *comparer) compareNewDir(pathA, pathB string, missingA bool) status {
+	dir := pathB
+	if !missingA {
+		dir = pathA
+	}
+	entries, err := readDirNames(dir)
+	if err != nil {
+		return c.fail(err)
+	}
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ret := same
+	for _, name := range names {
+		info := entries[name]
+		a, b := filepath.Join(pathA, name), filepath.Join(pathB, name)
+		infoA, infoB := info, info
+		ret = worse(ret, c.compareEntry(
+			a, b, infoA, infoB, !missingA, missingA,
+		))
+	}
+	return ret
+}
+
+// compareArgs compares the two operands from the command line. When one
+// operand is a directory and the other is a file, the file is compared
+// with the file of the same name in the directory.
+func (c *comparer) compareArgs(pathA, pathB string) status {
+	isDir := func(p string) (bool, error) {
+		if p == "-" {
+			return false, nil
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return false, err
+		}
+		return info.IsDir(), nil
+	}
+	dirA, err := isDir(pathA)
+	if err != nil {
+		return c.fail(err)
+	}
+	dirB, err := isDir(pathB)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	switch {
+	case dirA && dirB:
+		return c.compareDirs(pathA, pathB)
+	case dirA:
+		if pathB == "-" {
+			return c.fail(fmt.Errorf("cannot compare - to a directory"))
+		}
+		pathA = filepath.Join(pathA, filepath.Base(pathB))
+	case dirB:
+		if pathA == "-" {
+			return c.fail(fmt.Errorf("cannot compare - to a directory"))
+		}
+		pathB = filepath.Join(pathB, filepath.Base(pathA))
+	}
+	return c.compareFiles(pathA, pathB, false, false, "")
+}
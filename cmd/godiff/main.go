@@ -0,0 +1,172 @@
+// Command godiff compares files line by line, like GNU diff.
+//
+// It supports the normal, unified (-u), context (-c), RCS (-n),
+// side-by-side (-y), HTML (-html) and JSON (-json) output formats,
+// recursive directory comparison (-r), and ignoring white space, blank
+// line and case changes. With -intraline, the changed characters within
+// changed lines are highlighted in colored, HTML and JSON output. With
+// -chars, it uses the diffmp engine to print a character-level diff
+// instead.
+//
+// The exit status is 0 if the inputs are the same, 1 if they differ, and 2
+// if there was trouble.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"shanhu.io/third/diff"
+)
+
+// shortBools are the single-letter boolean flags that can be grouped, and
+// shortValues are the single-letter flags that take a value.
+const (
+	shortBools  = "ucnyrNqbwZBi"
+	shortValues = "UCW"
+)
+
+func parseFlags(args []string, stderr io.Writer) (
+	*options, []string, error,
+) {
+	fs := flag.NewFlagSet("godiff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	unified := fs.Bool("u", false, "output 3 lines of unified context")
+	unifiedN := fs.Int("U", -1, "output `N` lines of unified context")
+	context := fs.Bool("c", false, "output 3 lines of copied context")
+	contextN := fs.Int("C", -1, "output `N` lines of copied context")
+	rcs := fs.Bool("n", false, "output an RCS format diff")
+	side := fs.Bool("y", false, "output in two columns")
+	width := fs.Int("W", 130, "output at most `N` columns with -y")
+	recursive := fs.Bool("r", false, "recursively compare subdirectories")
+	newFile := fs.Bool("N", false, "treat absent files as empty")
+	brief := fs.Bool("q", false, "only report when files differ")
+	spaceChange := fs.Bool("b", false, "ignore changes in amount of space")
+	allSpace := fs.Bool("w", false, "ignore all white space")
+	trailing := fs.Bool("Z", false, "ignore white space at line end")
+	blank := fs.Bool("B", false, "ignore changes of only blank lines")
+	ignoreCase := fs.Bool("i", false, "ignore case differences")
+	stripCR := fs.Bool(
+		"strip-trailing-cr", false, "ignore carriage returns at line end",
+	)
+	color := fs.String("color", "never", "color output: never/always/auto")
+	chars := fs.Bool("chars", false, "character-level diff with diffmp")
+	intraline := fs.Bool(
+		"intraline", false, "highlight changed characters in changed lines",
+	)
+	htmlOut := fs.Bool("html", false, "output an HTML table")
+	jsonOut := fs.Bool("json", false, "output one JSON object per file")
+	var lbls labels
+	fs.Var(&lbls, "label", "use `LABEL` instead of file name and time")
+
+	expanded := expandShortFlags(args, shortBools, shortValues)
+	if err := fs.Parse(expanded); err != nil {
+		return nil, nil, err
+	}
+
+	opts := &options{
+		format:      formatNormal,
+		width:       *width,
+		recursive:   *recursive,
+		newFile:     *newFile,
+		brief:       *brief,
+		chars:       *chars,
+		intraline:   *intraline,
+		ignoreBlank: *blank,
+		normalize:   *stripCR,
+		labels:      lbls,
+	}
+	switch {
+	case *jsonOut || *htmlOut:
+		opts.format = formatHTML
+		if *jsonOut {
+			opts.format = formatJSON
+		}
+		opts.context = 3
+		if *unifiedN >= 0 {
+			opts.context = *unifiedN
+		}
+	case *side:
+		opts.format = formatSideBySide
+	case *rcs:
+		opts.format = formatRCS
+	case *context || *contextN >= 0:
+		opts.format = formatContext
+		opts.context = 3
+		if *contextN >= 0 {
+			opts.context = *contextN
+		}
+	case *unified || *unifiedN >= 0:
+		opts.format = formatUnified
+		opts.context = 3
+		if *unifiedN >= 0 {
+			opts.context = *unifiedN
+		}
+	}
+
+	var keys []func(string) string
+	if *allSpace {
+		keys = append(keys, diff.IgnoreAllSpace)
+	} else if *spaceChange {
+		keys = append(keys, diff.IgnoreSpaceChange)
+	} else if *trailing {
+		keys = append(keys, diff.IgnoreTrailingSpace)
+	}
+	if *ignoreCase {
+		keys = append(keys, diff.IgnoreCase)
+	}
+	opts.key = diff.ChainKeys(keys...)
+
+	switch *color {
+	case "always":
+		opts.colors = diff.DefaultColors
+	case "auto":
+		if isTerminal(os.Stdout) {
+			opts.colors = diff.DefaultColors
+		}
+	case "never":
+	default:
+		return nil, nil, fmt.Errorf("invalid -color value %q", *color)
+	}
+
+	rest := fs.Args()
+	opts.cmdLine = "diff " + strings.Join(args[:len(args)-len(rest)], " ")
+	opts.cmdLine = strings.TrimSpace(opts.cmdLine)
+	return opts, rest, nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func run(args []string, stdout, stderr io.Writer) status {
+	opts, files, err := parseFlags(args, stderr)
+	if err == flag.ErrHelp {
+		return same
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "godiff: %s\n", err)
+		return trouble
+	}
+	if len(files) != 2 {
+		fmt.Fprintf(stderr, "godiff: need two files to compare\n")
+		return trouble
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	c := &comparer{opts: opts, out: out, errs: stderr}
+	return c.compareArgs(files[0], files[1])
+}
+
+func main() {
+	os.Exit(int(run(os.Args[1:], os.Stdout, os.Stderr)))
+}
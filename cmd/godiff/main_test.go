@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandShortFlags(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-ur", "a", "b"}, []string{"-u", "-r", "a", "b"}},
+		{[]string{"-U1", "a"}, []string{"-U", "1", "a"}},
+		{[]string{"-C5"}, []string{"-C", "5"}},
+		{[]string{"-rU", "2"}, []string{"-r", "-U", "2"}},
+		{[]string{"-NrC10"}, []string{"-N", "-r", "-C", "10"}},
+		{[]string{"-U", "1"}, []string{"-U", "1"}},
+		{[]string{"-chars"}, []string{"-chars"}},
+		{[]string{"-label=x"}, []string{"-label=x"}},
+		{[]string{"--", "-ur"}, []string{"--", "-ur"}},
+	} {
+		got := expandShortFlags(test.args, shortBools, shortValues)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf(
+				"expandShortFlags(%q): got %q, want %q",
+				test.args, got, test.want,
+			)
+		}
+	}
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func runGodiff(args ...string) (status, string, string) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	s := run(args, stdout, stderr)
+	return s, stdout.String(), stderr.String()
+}
+
+func TestRunStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "godiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"a": "1\n2\n3\n",
+		"b": "1\n2\n3\n",
+		"c": "1\nx\n3\n",
+	})
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	c := filepath.Join(dir, "c")
+	missing := filepath.Join(dir, "missing")
+
+	for _, test := range []struct {
+		args []string
+		want status
+	}{
+		{[]string{a, b}, same},
+		{[]string{a, c}, differ},
+		{[]string{"-q", a, c}, differ},
+		{[]string{a, missing}, trouble},
+		{[]string{a}, trouble},
+		{[]string{"-no-such-flag", a, b}, trouble},
+	} {
+		got, _, _ := runGodiff(test.args...)
+		if got != test.want {
+			t.Errorf(
+				"run(%q): got status %d, want %d",
+				test.args, got, test.want,
+			)
+		}
+	}
+}
+
+func TestRunLabel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "godiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"a": "1\n2\n3\n4\n5\n",
+		"b": "1\n2\nx\n4\n5\n",
+	})
+
+	s, out, _ := runGodiff(
+		"-U1", "--label", "old", "--label", "new",
+		filepath.Join(dir, "a"), filepath.Join(dir, "b"),
+	)
+	if s != differ {
+		t.Errorf("got status %d, want %d", s, differ)
+	}
+	want := strings.Join([]string{
+		"--- old",
+		"+++ new",
+		"@@ -2,3 +2,3 @@",
+		" 2",
+		"-3",
+		"+x",
+		" 4",
+		"",
+	}, "\n")
+	if out != want {
+		t.Errorf("got output %q, want %q", out, want)
+	}
+}
+
+func TestRunRecursive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "godiff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeTestFiles(t, dir, map[string]string{
+		"a/same":  "same\n",
+		"a/only":  "only\n",
+		"a/sub/f": "a\n",
+		"b/same":  "same\n",
+		"b/sub/f": "b\n",
+	})
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+
+	s, out, _ := runGodiff("-ur", a, b)
+	if s != differ {
+		t.Errorf("got status %d, want %d", s, differ)
+	}
+	for _, want := range []string{
+		"Only in " + a + ": only\n",
+		"diff -ur " + filepath.Join(a, "sub/f") + " " +
+			filepath.Join(b, "sub/f") + "\n",
+		"-a\n+b\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q does not contain %q", out, want)
+		}
+	}
+	if strings.Contains(out, "same") {
+		t.Errorf("output %q has the same files", out)
+	}
+
+	// Without -r, subdirectories are only listed.
+	_, out, _ = runGodiff("-u", a, b)
+	if strings.Contains(out, "-a\n") {
+		t.Errorf("output %q compares subdirectories without -r", out)
+	}
+
+	// With -N, a file only in one directory is compared to an empty file.
+	s, out, _ = runGodiff("-urN", a, b)
+	if s != differ {
+		t.Errorf("got status %d, want %d", s, differ)
+	}
+	if !strings.Contains(out, "@@ -1 +0,0 @@\n-only\n") {
+		t.Errorf("output %q does not delete the absent file", out)
+	}
+
+	s, _, _ = runGodiff("-rN", a, a)
+	if s != same {
+		t.Errorf("got status %d, want %d", s, same)
+	}
+}
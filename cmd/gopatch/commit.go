@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"shanhu.io/third/diff"
+)
+
+// rename moves files into place when committing. Tests replace it to
+// make a commit fail partway.
+var rename = os.Rename
+
+// swap replaces one file on the disk, and can be undone.
+type swap struct {
+	path   string
+	temp   string // new content; empty if the file is deleted
+	backup string // original file moved aside; empty if there was none
+	placed bool
+}
+
+func writeTemp(path string, data []byte, perm os.FileMode) (string, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	f, err := ioutil.TempFile(dir, ".gopatch-")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (s *swap) do() error {
+	if _, err := os.Lstat(s.path); err == nil {
+		f, err := ioutil.TempFile(filepath.Dir(s.path), ".gopatch-orig-")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		if err := rename(s.path, f.Name()); err != nil {
+			os.Remove(f.Name())
+			return err
+		}
+		s.backup = f.Name()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if s.temp == "" {
+		return nil
+	}
+	if err := rename(s.temp, s.path); err != nil {
+		return err
+	}
+	s.placed = true
+	return nil
+}
+
+func (s *swap) undo() {
+	if s.placed {
+		os.Remove(s.path)
+	}
+	if s.backup != "" {
+		os.Rename(s.backup, s.path)
+	}
+}
+
+// commit writes all changed files. New contents are first written into
+// temporary files next to their targets, and then renamed into place. If
+// any step fails, the files that are already replaced are restored.
+func (p *patcher) commit() error {
+	var swaps []*swap
+	removeTemps := func() {
+		for _, s := range swaps {
+			if s.temp != "" && !s.placed {
+				os.Remove(s.temp)
+			}
+		}
+	}
+
+	for _, path := range p.order {
+		st := p.files[path]
+		if !st.changed {
+			continue
+		}
+		s := &swap{path: path}
+		swaps = append(swaps, s)
+		if !st.exists {
+			continue
+		}
+		temp, err := writeTemp(path, st.data, st.perm)
+		if err != nil {
+			removeTemps()
+			return err
+		}
+		s.temp = temp
+	}
+
+	for i, s := range swaps {
+		if err := s.do(); err != nil {
+			for j := i; j >= 0; j-- {
+				swaps[j].undo()
+			}
+			removeTemps()
+			return err
+		}
+	}
+	for _, s := range swaps {
+		if s.backup != "" {
+			os.Remove(s.backup)
+		}
+	}
+	return nil
+}
+
+func (p *patcher) writeRejects() error {
+	for _, r := range p.rejects {
+		buf := new(bytes.Buffer)
+		oldName, newName := r.patch.OldName, r.patch.NewName
+		if oldName == "" {
+			oldName = diff.DevNull
+		}
+		if newName == "" {
+			newName = diff.DevNull
+		}
+		fmt.Fprintf(buf, "--- %s\n+++ %s\n", oldName, newName)
+		if err := diff.WriteHunks(buf, r.hunks); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(r.path, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *patcher) fail(err error) status {
+	fmt.Fprintf(p.errs, "gopatch: %s\n", err)
+	return trouble
+}
+
+// run plans all file patches, and changes the files only if all of them
+// apply.
+func (p *patcher) run(patches []*diff.FilePatch) status {
+	for _, fp := range patches {
+		if err := p.patchFile(fp); err != nil {
+			return p.fail(err)
+		}
+	}
+	if p.opts.dryRun {
+		if p.failed {
+			return rejected
+		}
+		return applied
+	}
+	if p.failed {
+		if err := p.writeRejects(); err != nil {
+			return p.fail(err)
+		}
+		fmt.Fprintln(p.out, "no file is changed because of rejected hunks")
+		return rejected
+	}
+	if err := p.commit(); err != nil {
+		return p.fail(err)
+	}
+	return applied
+}
@@ -0,0 +1,149 @@
+// Command gopatch applies unified and git style patches, like GNU patch.
+//
+// It reads the patch from -i or the standard input. A patch can change
+// many files, and can create, delete and rename files, or carry git binary
+// patches. When a hunk does not match exactly, it is searched at nearby
+// lines, and up to -F lines of context might be ignored.
+//
+// Files are changed atomically: if any hunk is rejected, no file is
+// changed, and the rejected hunks are saved in .rej files next to the
+// files.
+//
+// The exit status is 0 if all hunks are applied, 1 if some hunks are
+// rejected, and 2 if there was trouble.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"shanhu.io/third/diff"
+)
+
+// status is the exit status of the program.
+type status int
+
+const (
+	applied  status = 0
+	rejected status = 1
+	trouble  status = 2
+)
+
+type options struct {
+	strip   int // path components to strip; -1 for auto
+	reverse bool
+	dryRun  bool
+	fuzz    int
+	dir     string
+	quiet   bool
+}
+
+// attachedFlags are the single-letter flags that can take their value
+// in the same argument, like "-p1".
+const attachedFlags = "pFdi"
+
+// splitAttached rewrites arguments like "-p1" into "-p=1" as patch(1)
+// users are used to.
+func splitAttached(args []string) []string {
+	var ret []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(ret, args[i:]...)
+		}
+		if len(arg) > 2 && arg[0] == '-' &&
+			strings.IndexByte(attachedFlags, arg[1]) >= 0 && arg[2] != '=' {
+			arg = arg[:2] + "=" + arg[2:]
+		}
+		ret = append(ret, arg)
+	}
+	return ret
+}
+
+func parseFlags(args []string, stderr io.Writer) (
+	*options, string, error,
+) {
+	fs := flag.NewFlagSet("gopatch", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	strip := fs.Int(
+		"p", -1, "strip `N` leading path components; "+
+			"default is 1 for git patches and 0 otherwise",
+	)
+	input := fs.String("i", "", "read the patch from `FILE`")
+	reverse := fs.Bool("R", false, "apply the patch in reverse")
+	dryRun := fs.Bool("dry-run", false, "check the patch but change nothing")
+	fuzz := fs.Int("F", 2, "ignore at most `N` lines of context")
+	dir := fs.String("d", "", "change to `DIR` before patching")
+	quiet := fs.Bool("s", false, "only print errors")
+
+	if err := fs.Parse(splitAttached(args)); err != nil {
+		return nil, "", err
+	}
+	if fs.NArg() > 0 {
+		return nil, "", fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if *fuzz < 0 {
+		return nil, "", fmt.Errorf("invalid fuzz %d", *fuzz)
+	}
+	return &options{
+		strip:   *strip,
+		reverse: *reverse,
+		dryRun:  *dryRun,
+		fuzz:    *fuzz,
+		dir:     *dir,
+		quiet:   *quiet,
+	}, *input, nil
+}
+
+func readPatch(input string) ([]*diff.FilePatch, error) {
+	var bs []byte
+	var err error
+	if input == "" || input == "-" {
+		bs, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		bs, err = ioutil.ReadFile(input)
+	}
+	if err != nil {
+		return nil, err
+	}
+	patches, err := diff.ParsePatch(string(bs))
+	if err != nil {
+		return nil, err
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("only garbage was found in the patch input")
+	}
+	return patches, nil
+}
+
+func run(args []string, stdout, stderr io.Writer) status {
+	opts, input, err := parseFlags(args, stderr)
+	if err == flag.ErrHelp {
+		return applied
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "gopatch: %s\n", err)
+		return trouble
+	}
+	patches, err := readPatch(input)
+	if err != nil {
+		fmt.Fprintf(stderr, "gopatch: %s\n", err)
+		return trouble
+	}
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	p := newPatcher(opts, out, stderr)
+	if opts.quiet {
+		p.out = ioutil.Discard
+	}
+	return p.run(patches)
+}
+
+func main() {
+	os.Exit(int(run(os.Args[1:], os.Stdout, os.Stderr)))
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gopatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFiles reads all files under dir, keyed by slash separated path.
+func readFiles(t *testing.T, dir string) map[string]string {
+	ret := make(map[string]string)
+	walk := func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		bs, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		ret[filepath.ToSlash(rel)] = string(bs)
+		return nil
+	}
+	if err := filepath.Walk(dir, walk); err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func checkFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	got := readFiles(t, dir)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got files %q, want %q", got, want)
+	}
+}
+
+// runPatch writes the patch into the parent of dir, and runs gopatch on
+// dir with it.
+func runPatch(t *testing.T, dir, patch string, args ...string) (
+	status, string,
+) {
+	input := dir + ".patch"
+	if err := ioutil.WriteFile(input, []byte(patch), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(input)
+
+	args = append(args, "-i", input, "-d", dir)
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	s := run(args, stdout, stderr)
+	return s, stdout.String() + stderr.String()
+}
+
+var testFiles = map[string]string{
+	"a":       "1\n2\n3\n",
+	"d":       "gone\n",
+	"r":       "moved\n",
+	"sub/b":   "x\ny\nz\n",
+	"keep.go": "package keep\n",
+}
+
+const testPatch = `diff --git a/a b/a
+--- a/a
++++ b/a
+@@ -1,3 +1,3 @@
+ 1
+-2
++two
+ 3
+diff --git a/c b/c
+new file mode 100644
+--- /dev/null
++++ b/c
+@@ -0,0 +1 @@
++created
+diff --git a/d b/d
+deleted file mode 100644
+--- a/d
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/r b/s
+similarity index 100%
+rename from r
+rename to s
+diff --git a/sub/b b/sub/b
+--- a/sub/b
++++ b/sub/b
+@@ -1,3 +1,3 @@
+ x
+-y
++why
+ z
+`
+
+var testPatched = map[string]string{
+	"a":       "1\ntwo\n3\n",
+	"c":       "created\n",
+	"s":       "moved\n",
+	"sub/b":   "x\nwhy\nz\n",
+	"keep.go": "package keep\n",
+}
+
+func TestRunApply(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, testFiles)
+
+	if s, out := runPatch(t, dir, testPatch); s != applied {
+		t.Fatalf("got status %d, want %d; output:\n%s", s, applied, out)
+	}
+	checkFiles(t, dir, testPatched)
+
+	// Applying it in reverse restores the files.
+	if s, out := runPatch(t, dir, testPatch, "-R"); s != applied {
+		t.Fatalf("got status %d, want %d; output:\n%s", s, applied, out)
+	}
+	checkFiles(t, dir, testFiles)
+}
+
+func TestRunDryRun(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, testFiles)
+
+	s, out := runPatch(t, dir, testPatch, "--dry-run")
+	if s != applied {
+		t.Errorf("got status %d, want %d; output:\n%s", s, applied, out)
+	}
+	if !strings.Contains(out, "checking file sub/b\n") {
+		t.Errorf("output %q does not check sub/b", out)
+	}
+	checkFiles(t, dir, testFiles)
+
+	// A failing dry run reports the rejects, but does not save them.
+	bad := strings.Replace(testPatch, "-y\n", "-not y\n", 1)
+	if s, _ := runPatch(t, dir, bad, "--dry-run"); s != rejected {
+		t.Errorf("got status %d, want %d", s, rejected)
+	}
+	checkFiles(t, dir, testFiles)
+}
+
+func TestRunReject(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, testFiles)
+
+	bad := strings.Replace(testPatch, "-y\n", "-not y\n", 1)
+	s, out := runPatch(t, dir, bad)
+	if s != rejected {
+		t.Errorf("got status %d, want %d; output:\n%s", s, rejected, out)
+	}
+	if !strings.Contains(out, "saving rejects to file sub/b.rej") {
+		t.Errorf("output %q does not save rejects", out)
+	}
+
+	// No file is changed; only the rejects are saved.
+	want := make(map[string]string)
+	for k, v := range testFiles {
+		want[k] = v
+	}
+	want["sub/b.rej"] = strings.Join([]string{
+		"--- a/sub/b",
+		"+++ b/sub/b",
+		"@@ -1,3 +1,3 @@",
+		" x",
+		"-not y",
+		"+why",
+		" z",
+		"",
+	}, "\n")
+	checkFiles(t, dir, want)
+}
+
+func TestRunStrip(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{"f": "old\n"})
+
+	patch := strings.Join([]string{
+		"--- x/y/f",
+		"+++ x/y/f",
+		"@@ -1 +1 @@",
+		"-old",
+		"+new",
+		"",
+	}, "\n")
+	if s, _ := runPatch(t, dir, patch, "-p1"); s != trouble {
+		t.Errorf("-p1: got status %d, want %d", s, trouble)
+	}
+	checkFiles(t, dir, map[string]string{"f": "old\n"})
+
+	if s, out := runPatch(t, dir, patch, "-p2"); s != applied {
+		t.Errorf(
+			"-p2: got status %d, want %d; output:\n%s", s, applied, out,
+		)
+	}
+	checkFiles(t, dir, map[string]string{"f": "new\n"})
+}
+
+func TestRunRollback(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, testFiles)
+
+	// Fail when sub/b is put into place, after the files before it in
+	// the patch are already replaced.
+	failAt := filepath.Join(dir, "sub", "b")
+	defer func() { rename = os.Rename }()
+	var renamed []string
+	rename = func(from, to string) error {
+		if to == failAt {
+			return fmt.Errorf("rename %s: test failure", to)
+		}
+		renamed = append(renamed, to)
+		return os.Rename(from, to)
+	}
+
+	s, out := runPatch(t, dir, testPatch)
+	if s != trouble {
+		t.Errorf("got status %d, want %d; output:\n%s", s, trouble, out)
+	}
+	if !strings.Contains(out, "test failure") {
+		t.Errorf("output %q does not report the failure", out)
+	}
+	placed := false
+	for _, p := range renamed {
+		if p == filepath.Join(dir, "a") {
+			placed = true
+		}
+	}
+	if !placed {
+		t.Errorf("a is not replaced before the failure: %q", renamed)
+	}
+
+	// All files are restored, with no temporary files left behind.
+	checkFiles(t, dir, testFiles)
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"shanhu.io/third/diff"
+)
+
+// fileState is the pending state of a file while a patch is planned.
+type fileState struct {
+	data    []byte
+	exists  bool
+	perm    os.FileMode
+	changed bool
+}
+
+// reject is a set of hunks of a file patch that failed to apply.
+type reject struct {
+	path  string
+	patch *diff.FilePatch
+	hunks []*diff.Hunk
+}
+
+// patcher plans all file patches in memory before changing any file, so
+// that later file patches see the result of earlier ones, and nothing is
+// changed if any of them fails.
+type patcher struct {
+	opts    *options
+	out     io.Writer
+	errs    io.Writer
+	files   map[string]*fileState
+	order   []string
+	rejects []*reject
+	failed  bool
+}
+
+func newPatcher(opts *options, out, errs io.Writer) *patcher {
+	return &patcher{
+		opts:  opts,
+		out:   out,
+		errs:  errs,
+		files: make(map[string]*fileState),
+	}
+}
+
+// state returns the pending state of the file at path, reading it from
+// the disk the first time.
+func (p *patcher) state(path string) (*fileState, error) {
+	if s, ok := p.files[path]; ok {
+		return s, nil
+	}
+	s := &fileState{perm: 0644}
+	info, err := os.Stat(path)
+	if err == nil {
+		if info.IsDir() {
+			return nil, fmt.Errorf("%s is a directory", path)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		s.data, s.exists, s.perm = data, true, info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	p.files[path] = s
+	p.order = append(p.order, path)
+	return s, nil
+}
+
+// stripLevel returns the -p level for a file patch. By default, git style
+// names with "a/" and "b/" prefixes are stripped by one.
+func (p *patcher) stripLevel(fp *diff.FilePatch) int {
+	if p.opts.strip >= 0 {
+		return p.opts.strip
+	}
+	git := (fp.OldName == "" || strings.HasPrefix(fp.OldName, "a/")) &&
+		(fp.NewName == "" || strings.HasPrefix(fp.NewName, "b/"))
+	if git {
+		return 1
+	}
+	return 0
+}
+
+// fileName strips a name in the patch into a relative slash separated
+// path. An empty name stays empty.
+func fileName(name string, strip int) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	ret := diff.StripPath(name, strip)
+	if ret == "" {
+		return "", fmt.Errorf(
+			"cannot strip %d components from %q", strip, name,
+		)
+	}
+	if strings.HasPrefix(ret, "/") {
+		return "", fmt.Errorf("refusing absolute path %q", ret)
+	}
+	for _, part := range strings.Split(ret, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("refusing path %q with \"..\"", ret)
+		}
+	}
+	return ret, nil
+}
+
+func (p *patcher) diskPath(name string) string {
+	if name == "" {
+		return ""
+	}
+	return filepath.Join(p.opts.dir, filepath.FromSlash(name))
+}
+
+func parsePerm(mode string, def os.FileMode) os.FileMode {
+	if mode == "" {
+		return def
+	}
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return def
+	}
+	return os.FileMode(m).Perm()
+}
+
+func (p *patcher) patchFile(fp *diff.FilePatch) error {
+	strip := p.stripLevel(fp)
+	if p.opts.reverse {
+		fp = fp.Reverse()
+	}
+	oldName, err := fileName(fp.OldName, strip)
+	if err != nil {
+		return err
+	}
+	newName, err := fileName(fp.NewName, strip)
+	if err != nil {
+		return err
+	}
+	name := newName
+	if name == "" {
+		name = oldName
+	}
+	if name == "" {
+		return fmt.Errorf("file patch has no file name")
+	}
+
+	verb := "patching"
+	if p.opts.dryRun {
+		verb = "checking"
+	}
+	fmt.Fprintf(p.out, "%s file %s", verb, name)
+	if oldName != "" && newName != "" && oldName != newName {
+		if fp.IsCopy {
+			fmt.Fprintf(p.out, " (copied from %s)", oldName)
+		} else {
+			fmt.Fprintf(p.out, " (renamed from %s)", oldName)
+		}
+	}
+	fmt.Fprintln(p.out)
+
+	var src *fileState
+	if !fp.IsNew() {
+		src, err = p.state(p.diskPath(oldName))
+		if err != nil {
+			return err
+		}
+		if !src.exists {
+			return fmt.Errorf("%s: no such file", oldName)
+		}
+	} else {
+		dst, err := p.state(p.diskPath(newName))
+		if err != nil {
+			return err
+		}
+		if dst.exists && len(dst.data) > 0 {
+			return fmt.Errorf("%s: file already exists", newName)
+		}
+	}
+
+	var old []byte
+	if src != nil {
+		old = src.data
+	}
+	data, ok, err := p.patchContent(fp, name, old)
+	if err != nil || !ok {
+		return err
+	}
+
+	if fp.IsDelete() {
+		if len(data) > 0 {
+			fmt.Fprintf(
+				p.out, "Not deleting file %s as content differs "+
+					"from patch\n", name,
+			)
+			p.failed = true
+			return nil
+		}
+		src.data, src.exists, src.changed = nil, false, true
+		return nil
+	}
+
+	dst, err := p.state(p.diskPath(newName))
+	if err != nil {
+		return err
+	}
+	perm := dst.perm
+	if src != nil {
+		perm = src.perm
+	}
+	dst.perm = parsePerm(fp.NewMode, perm)
+	dst.data, dst.exists, dst.changed = data, true, true
+	if src != nil && src != dst && !fp.IsCopy {
+		src.data, src.exists, src.changed = nil, false, true
+	}
+	return nil
+}
+
+// patchContent applies the file patch on the old content. It returns
+// false if some hunks are rejected.
+func (p *patcher) patchContent(fp *diff.FilePatch, name string, old []byte) (
+	[]byte, bool, error,
+) {
+	if fp.Binary != nil {
+		data, err := fp.ApplyBinary(old)
+		if err != nil {
+			fmt.Fprintf(p.out, "binary patch FAILED: %s\n", err)
+			p.failed = true
+			return nil, false, nil
+		}
+		return data, true, nil
+	}
+	if fp.BinaryNoData {
+		return nil, false, fmt.Errorf(
+			"%s: binary patch has no data; "+
+				"create it with git diff --binary", name,
+		)
+	}
+
+	lines := diff.SplitFileLines(string(old))
+	out, results := diff.ApplyHunks(
+		lines, fp.Hunks, &diff.ApplyOptions{Fuzz: p.opts.fuzz},
+	)
+	var failed []*diff.Hunk
+	for i, r := range results {
+		if !r.Applied {
+			fmt.Fprintf(p.out, "Hunk #%d FAILED at %d.\n", i+1, r.Line)
+			failed = append(failed, fp.Hunks[i])
+			continue
+		}
+		if r.Offset == 0 && r.Fuzz == 0 {
+			continue
+		}
+		fmt.Fprintf(p.out, "Hunk #%d succeeded at %d", i+1, r.Line)
+		if r.Fuzz > 0 {
+			fmt.Fprintf(p.out, " with fuzz %d", r.Fuzz)
+		}
+		if r.Offset != 0 {
+			fmt.Fprintf(
+				p.out, " (offset %d line%s)", r.Offset, plural(r.Offset),
+			)
+		}
+		fmt.Fprintln(p.out, ".")
+	}
+
+	if len(failed) > 0 {
+		rej := name + ".rej"
+		fmt.Fprintf(
+			p.out, "%d out of %d hunk%s FAILED -- "+
+				"saving rejects to file %s\n",
+			len(failed), len(results), plural(len(results)), rej,
+		)
+		p.rejects = append(p.rejects, &reject{
+			path:  p.diskPath(rej),
+			patch: fp,
+			hunks: failed,
+		})
+		p.failed = true
+		return nil, false, nil
+	}
+	return []byte(strings.Join(out, "")), true, nil
+}
+
+func plural(n int) string {
+	if n == 1 || n == -1 {
+		return ""
+	}
+	return "s"
+}
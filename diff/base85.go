@@ -0,0 +1,116 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// base85Chars is the alphabet that git uses for binary patches. It is not
+// the same as the Ascii85 alphabet in package encoding/ascii85.
+const base85Chars = "0123456789" +
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz" +
+	"!#$%&()*+-;<=>?@^_`{|}~"
+
+var base85Values = func() [256]int {
+	var ret [256]int
+	for i := range ret {
+		ret[i] = -1
+	}
+	for i := 0; i < len(base85Chars); i++ {
+		ret[base85Chars[i]] = i
+	}
+	return ret
+}()
+
+// base85LineMax is the number of bytes encoded on one line.
+const base85LineMax = 52
+
+// encodeBase85 encodes data 4 bytes at a time into 5 characters each. The
+// last group is padded with zeros.
+func encodeBase85(buf *bytes.Buffer, data []byte) {
+	for len(data) > 0 {
+		var v uint32
+		for i := 0; i < 4; i++ {
+			v <<= 8
+			if i < len(data) {
+				v |= uint32(data[i])
+			}
+		}
+		var group [5]byte
+		for i := 4; i >= 0; i-- {
+			group[i] = base85Chars[v%85]
+			v /= 85
+		}
+		buf.Write(group[:])
+		if len(data) < 4 {
+			break
+		}
+		data = data[4:]
+	}
+}
+
+// decodeBase85 decodes s into n bytes.
+func decodeBase85(s string, n int) ([]byte, error) {
+	if len(s) != (n+3)/4*5 {
+		return nil, fmt.Errorf(
+			"base85: %d chars cannot hold %d bytes", len(s), n,
+		)
+	}
+	ret := make([]byte, 0, (n+3)/4*4)
+	for len(s) > 0 {
+		var v uint64
+		for i := 0; i < 5; i++ {
+			d := base85Values[s[i]]
+			if d < 0 {
+				return nil, fmt.Errorf("base85: invalid char %q", s[i])
+			}
+			v = v*85 + uint64(d)
+		}
+		if v > 0xffffffff {
+			return nil, fmt.Errorf("base85: group %q overflows", s[:5])
+		}
+		ret = append(ret, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+		s = s[5:]
+	}
+	return ret[:n], nil
+}
+
+// encodeBase85Lines encodes data into git binary patch lines. Each line
+// starts with a character that encodes the number of bytes on the line:
+// 'A' to 'Z' for 1 to 26, and 'a' to 'z' for 27 to 52.
+func encodeBase85Lines(data []byte) []string {
+	var lines []string
+	buf := new(bytes.Buffer)
+	for len(data) > 0 {
+		n := min(len(data), base85LineMax)
+		buf.Reset()
+		if n <= 26 {
+			buf.WriteByte(byte('A' + n - 1))
+		} else {
+			buf.WriteByte(byte('a' + n - 27))
+		}
+		encodeBase85(buf, data[:n])
+		lines = append(lines, buf.String())
+		data = data[n:]
+	}
+	return lines
+}
+
+// decodeBase85Line decodes one line of a git binary patch.
+func decodeBase85Line(line string) ([]byte, error) {
+	if len(line) == 0 {
+		return nil, fmt.Errorf("base85: empty line")
+	}
+	c := line[0]
+	var n int
+	switch {
+	case c >= 'A' && c <= 'Z':
+		n = int(c-'A') + 1
+	case c >= 'a' && c <= 'z':
+		n = int(c-'a') + 27
+	default:
+		return nil, fmt.Errorf("base85: invalid line length %q", c)
+	}
+	return decodeBase85(line[1:], n)
+}
@@ -0,0 +1,44 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBase85RoundTrip(t *testing.T) {
+	for n := 0; n < 200; n += 7 {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*31 + n)
+		}
+		var got []byte
+		for _, line := range encodeBase85Lines(data) {
+			bs, err := decodeBase85Line(line)
+			if err != nil {
+				t.Fatalf("decode %q: %s", line, err)
+			}
+			got = append(got, bs...)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("round trip of %d bytes, got %v", n, got)
+		}
+	}
+}
+
+func TestBase85Line(t *testing.T) {
+	// The empty literal hunk that git writes for deleted files.
+	got, err := decodeBase85Line("HcmV?d00001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x78, 0x01, 0x03, 0x00, 0x00, 0x00, 0x00, 0x01}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+
+	for _, bad := range []string{"", "0abcde", "Babc", "A~~~~~"} {
+		if _, err := decodeBase85Line(bad); err == nil {
+			t.Errorf("decode %q should fail", bad)
+		}
+	}
+}
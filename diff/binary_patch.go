@@ -0,0 +1,91 @@
+package diff
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io/ioutil"
+)
+
+// BinaryHunk is one hunk of a git binary patch. It either carries the
+// full content of the resulting file (a literal hunk) or a git delta that
+// turns the other side into the resulting file (a delta hunk).
+type BinaryHunk struct {
+	Delta bool   // true for a delta hunk, false for a literal hunk
+	Data  []byte // the inflated literal content or delta
+}
+
+// BinaryPatch is a "GIT binary patch" section. The forward hunk turns the
+// old content into the new content; the optional reverse hunk turns the
+// new content back into the old content.
+type BinaryPatch struct {
+	Forward *BinaryHunk
+	Reverse *BinaryHunk
+}
+
+func deflate(data []byte) []byte {
+	buf := new(bytes.Buffer)
+	w, _ := zlib.NewWriterLevel(buf, zlib.BestCompression)
+	w.Write(data) // writes to a bytes.Buffer never fail
+	w.Close()
+	return buf.Bytes()
+}
+
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// makeBinaryHunk creates a hunk that turns src into dst. Like git, it uses
+// a delta only when the compressed delta is smaller than the compressed
+// literal.
+func makeBinaryHunk(src, dst []byte) *BinaryHunk {
+	lit := &BinaryHunk{Data: dst}
+	if len(src) == 0 || len(dst) == 0 {
+		return lit
+	}
+	delta := makeDelta(src, dst)
+	if len(deflate(delta)) < len(deflate(dst)) {
+		return &BinaryHunk{Delta: true, Data: delta}
+	}
+	return lit
+}
+
+// MakeBinaryPatch creates a binary patch with both a forward and a reverse
+// hunk, so that the patch can be applied in both directions.
+func MakeBinaryPatch(a, b []byte) *BinaryPatch {
+	return &BinaryPatch{
+		Forward: makeBinaryHunk(a, b),
+		Reverse: makeBinaryHunk(b, a),
+	}
+}
+
+// ApplyBinaryHunk applies a binary hunk on src and returns the result.
+func ApplyBinaryHunk(src []byte, h *BinaryHunk) ([]byte, error) {
+	if !h.Delta {
+		return append([]byte{}, h.Data...), nil
+	}
+	return applyDelta(src, h.Data)
+}
+
+// ApplyBinaryPatch applies the forward hunk of a binary patch on the old
+// content and returns the new content.
+func ApplyBinaryPatch(old []byte, p *BinaryPatch) ([]byte, error) {
+	if p.Forward == nil {
+		return nil, fmt.Errorf("binary patch has no forward hunk")
+	}
+	return ApplyBinaryHunk(old, p.Forward)
+}
+
+// RevertBinaryPatch applies the reverse hunk of a binary patch on the new
+// content and returns the old content.
+func RevertBinaryPatch(cur []byte, p *BinaryPatch) ([]byte, error) {
+	if p.Reverse == nil {
+		return nil, fmt.Errorf("binary patch has no reverse hunk")
+	}
+	return ApplyBinaryHunk(cur, p.Reverse)
+}
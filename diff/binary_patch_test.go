@@ -0,0 +1,193 @@
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDeltaRoundTrip(t *testing.T) {
+	src := bytes.Repeat([]byte("0123456789abcdef"), 100)
+	dst := append([]byte("header"), src[:700]...)
+	dst = append(dst, "middle"...)
+	dst = append(dst, src[900:]...)
+
+	delta := makeDelta(src, dst)
+	if len(delta) > 100 {
+		t.Errorf("delta has %d bytes, too large", len(delta))
+	}
+	got, err := applyDelta(src, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, dst) {
+		t.Errorf("delta round trip failed")
+	}
+
+	if _, err := applyDelta(src[1:], delta); err == nil {
+		t.Errorf("applying on wrong source should fail")
+	}
+}
+
+func TestBinaryPatchRoundTrip(t *testing.T) {
+	a := bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 300)
+	b := append([]byte{0xff}, a[:2000]...)
+	b = append(b, a[2500:]...)
+
+	p := MakeBinaryPatch(a, b)
+	if !p.Forward.Delta || !p.Reverse.Delta {
+		t.Errorf("similar content should use delta hunks")
+	}
+	text, err := BinaryPatchString(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseBinaryPatch(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ApplyBinaryPatch(a, parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, b) {
+		t.Errorf("forward apply failed")
+	}
+	got, err = RevertBinaryPatch(b, parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, a) {
+		t.Errorf("reverse apply failed")
+	}
+}
+
+func TestParseGitBinaryPatch(t *testing.T) {
+	// Produced by "git diff --binary".
+	for _, test := range []struct {
+		patch    string
+		old, new []byte
+	}{{
+		patch: strings.Join([]string{
+			"GIT binary patch",
+			"literal 21",
+			"ccmYdHN@j3zbz@{o&&X6r%u7+o%`Zv?06+5vR{#J2",
+			"",
+			"literal 12",
+			"TcmYdHN@hq&O=DzA&&UJ-7j6TQ",
+			"",
+		}, "\n"),
+		old: []byte("abc\x00def\x01\x02ghi"),
+		new: []byte("abc\x00DEF\x01\x02ghi and more"),
+	}, {
+		patch: strings.Join([]string{
+			"GIT binary patch",
+			"delta 15",
+			"VcmZo*X<(U<!V(1p8#87w0stoj1sebW",
+			"",
+			"delta 10",
+			"PcmZo*X<%8z$OuFL4+sKL",
+			"",
+		}, "\n"),
+		old: func() []byte {
+			var bs []byte
+			for i := 0; i < 512; i++ {
+				bs = append(bs, byte(i))
+			}
+			return bs
+		}(),
+		new: func() []byte {
+			var bs []byte
+			for i := 0; i < 512; i++ {
+				bs = append(bs, byte(i))
+			}
+			copy(bs[100:104], "ZZZZ")
+			return bs
+		}(),
+	}} {
+		p, err := ParseBinaryPatch(test.patch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ApplyBinaryPatch(test.old, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, test.new) {
+			t.Errorf("forward apply got %q, want %q", got, test.new)
+		}
+		got, err = RevertBinaryPatch(test.new, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, test.old) {
+			t.Errorf("reverse apply got %q, want %q", got, test.old)
+		}
+	}
+}
+
+func TestGitBinaryDiff(t *testing.T) {
+	a := NewBytesFile("x.bin", []byte("abc\x00def\x01\x02ghi"))
+	b := NewBytesFile("x.bin", []byte("abc\x00DEF\x01\x02ghi and more"))
+	if !a.Binary || !b.Binary {
+		t.Fatal("files should be binary")
+	}
+
+	got, err := UnifiedDiffString(&Input{A: a, B: b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Binary files x.bin and x.bin differ\n"; got != want {
+		t.Errorf("unified diff got %q, want %q", got, want)
+	}
+
+	got, err = GitBinaryDiffString(&Input{A: a, B: b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := "diff --git a/x.bin b/x.bin\n" +
+		"index e1223ed9cf3b60ec646004d82da4150624c6eacb.." +
+		"9d955a1c3a8e7661b811b6fa9b17614dd5134bcf 100644\n" +
+		"GIT binary patch\n"
+	if !strings.HasPrefix(got, header) {
+		t.Errorf("git binary diff got %q", got)
+	}
+
+	got, err = GitBinaryDiffString(&Input{A: nil, B: b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "new file mode 100644\n") ||
+		!strings.Contains(got, "\n\nliteral 0\n") {
+		t.Errorf("git binary diff for new file got %q", got)
+	}
+}
+
+func TestApplyDeltaMalformed(t *testing.T) {
+	src := []byte("abc")
+	for _, test := range []struct {
+		name  string
+		delta []byte
+	}{
+		{"empty", nil},
+		{"truncated size", []byte{0x83}},
+		{"overflow", []byte{
+			0x03, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01,
+		}},
+		{"63 bits", []byte{
+			0x03, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x01,
+		}},
+		{"huge target", []byte{
+			0x03, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f,
+		}},
+		{"short target", []byte{0x03, 0x01, 0x02, 'x', 'y'}},
+		{"long copy", []byte{0x03, 0x01, 0x90, 0x03}},
+		{"truncated insert", []byte{0x03, 0x02, 0x02, 'x'}},
+		{"truncated copy", []byte{0x03, 0x02, 0x90}},
+		{"reserved", []byte{0x03, 0x00, 0x00}},
+	} {
+		if _, err := applyDelta(src, test.delta); err == nil {
+			t.Errorf("%s: applying %q should fail", test.name, test.delta)
+		}
+	}
+}
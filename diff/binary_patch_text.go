@@ -0,0 +1,145 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const binaryPatchHeader = "GIT binary patch"
+
+func writeBinaryHunk(w io.Writer, h *BinaryHunk) error {
+	kind := "literal"
+	if h.Delta {
+		kind = "delta"
+	}
+	if _, err := fmt.Fprintf(w, "%s %d\n", kind, len(h.Data)); err != nil {
+		return err
+	}
+	for _, line := range encodeBase85Lines(deflate(h.Data)) {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// WriteBinaryPatch writes a binary patch in git's format, starting with the
+// "GIT binary patch" line. Each hunk is zlib compressed, base85 encoded, and
+// followed by an empty line.
+func WriteBinaryPatch(w io.Writer, p *BinaryPatch) error {
+	if p.Forward == nil {
+		return fmt.Errorf("binary patch has no forward hunk")
+	}
+	if _, err := fmt.Fprintln(w, binaryPatchHeader); err != nil {
+		return err
+	}
+	if err := writeBinaryHunk(w, p.Forward); err != nil {
+		return err
+	}
+	if p.Reverse != nil {
+		return writeBinaryHunk(w, p.Reverse)
+	}
+	return nil
+}
+
+// BinaryPatchString works like WriteBinaryPatch but returns the patch as a
+// string.
+func BinaryPatchString(p *BinaryPatch) (string, error) {
+	buf := new(bytes.Buffer)
+	err := WriteBinaryPatch(buf, p)
+	return buf.String(), err
+}
+
+// parseBinaryHunk parses a hunk that starts at lines[0]. It returns the
+// hunk and the number of lines consumed, including the ending empty line.
+func parseBinaryHunk(lines []string) (*BinaryHunk, int, error) {
+	h := new(BinaryHunk)
+	head := strings.TrimRight(lines[0], "\r\n")
+	var sizeStr string
+	if strings.HasPrefix(head, "literal ") {
+		sizeStr = strings.TrimPrefix(head, "literal ")
+	} else if strings.HasPrefix(head, "delta ") {
+		h.Delta = true
+		sizeStr = strings.TrimPrefix(head, "delta ")
+	} else {
+		return nil, 0, fmt.Errorf("invalid binary hunk header: %q", head)
+	}
+	size, err := strconv.Atoi(sizeStr)
+	if err != nil || size < 0 {
+		return nil, 0, fmt.Errorf("invalid binary hunk size: %q", head)
+	}
+
+	compressed := new(bytes.Buffer)
+	n := 1
+	for ; n < len(lines); n++ {
+		line := strings.TrimRight(lines[n], "\r\n")
+		if line == "" {
+			n++
+			break
+		}
+		bs, err := decodeBase85Line(line)
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d of binary hunk: %s", n, err)
+		}
+		compressed.Write(bs)
+	}
+
+	data, err := inflate(compressed.Bytes())
+	if err != nil {
+		return nil, 0, fmt.Errorf("inflate binary hunk: %s", err)
+	}
+	if len(data) != size {
+		return nil, 0, fmt.Errorf(
+			"binary hunk size is %d, want %d", len(data), size,
+		)
+	}
+	h.Data = data
+	return h, n, nil
+}
+
+func isBinaryHunkHeader(line string) bool {
+	return strings.HasPrefix(line, "literal ") ||
+		strings.HasPrefix(line, "delta ")
+}
+
+// parseBinaryPatch parses a binary patch that starts with the
+// "GIT binary patch" line at lines[0]. It returns the patch and the number
+// of lines consumed.
+func parseBinaryPatch(lines []string) (*BinaryPatch, int, error) {
+	if len(lines) == 0 ||
+		strings.TrimRight(lines[0], "\r\n") != binaryPatchHeader {
+		return nil, 0, fmt.Errorf("missing %q line", binaryPatchHeader)
+	}
+	n := 1
+	if n >= len(lines) {
+		return nil, 0, fmt.Errorf("binary patch has no forward hunk")
+	}
+	p := new(BinaryPatch)
+	h, consumed, err := parseBinaryHunk(lines[n:])
+	if err != nil {
+		return nil, 0, err
+	}
+	p.Forward = h
+	n += consumed
+
+	if n < len(lines) && isBinaryHunkHeader(lines[n]) {
+		h, consumed, err := parseBinaryHunk(lines[n:])
+		if err != nil {
+			return nil, 0, err
+		}
+		p.Reverse = h
+		n += consumed
+	}
+	return p, n, nil
+}
+
+// ParseBinaryPatch parses a binary patch in git's format, which starts with
+// a "GIT binary patch" line.
+func ParseBinaryPatch(s string) (*BinaryPatch, error) {
+	p, _, err := parseBinaryPatch(strings.SplitAfter(s, "\n"))
+	return p, err
+}
@@ -0,0 +1,136 @@
+package diff
+
+import (
+	"shanhu.io/third/diffmp"
+)
+
+// Revision is a revision of a text in a history, for Blame.
+type Revision struct {
+	// Author and Meta describe the revision. Blame does not use them; they
+	// are for the caller to look up from BlameLine.Rev.
+	Author string
+	Meta   interface{}
+
+	// Lines are the lines of the text, such as from SplitLines.
+	Lines []string
+}
+
+// BlameLine tells which revision introduced a line of the last revision.
+type BlameLine struct {
+	// Text is the line in the last revision.
+	Text string
+
+	// Rev is the index of the revision that introduced the line, and Line
+	// is the 1-based line number of the line in that revision.
+	Rev  int
+	Line int
+}
+
+// BlameOptions configures Blame.
+type BlameOptions struct {
+	// IgnoreSpace keeps the older attribution of lines whose only changes
+	// are in white space.
+	IgnoreSpace bool
+
+	// LineMode matches the lines of successive revisions with diffmp
+	// line-mode diffs, in place of a SequenceMatcher. It is faster on
+	// long texts, and does not treat popular lines as junk.
+	LineMode bool
+}
+
+// matchLines maps each line of b to the line of a that it is kept from,
+// or to -1 if it is new.
+type matchLines func(a, b []string) []int
+
+func newLineMap(n int) []int {
+	ret := make([]int, n)
+	for i := range ret {
+		ret[i] = -1
+	}
+	return ret
+}
+
+func matcherLines(a, b []string) []int {
+	ret := newLineMap(len(b))
+	for _, c := range NewMatcher(a, b).OpCodes() {
+		if c.Tag != 'e' {
+			continue
+		}
+		for j := c.J1; j < c.J2; j++ {
+			ret[j] = c.I1 + j - c.J1
+		}
+	}
+	return ret
+}
+
+func lineModeLines(a, b []string) []int {
+	ids := make(map[string]int32)
+	lineIDs := func(lines []string) []int32 {
+		ret := make([]int32, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = int32(len(ids))
+				ids[line] = id
+			}
+			ret[i] = id
+		}
+		return ret
+	}
+	ida, idb := lineIDs(a), lineIDs(b)
+
+	ret := newLineMap(len(b))
+	i, j := 0, 0
+	for _, d := range diffmp.New().DiffMainIDs(ida, idb) {
+		n := len(d.IDs)
+		switch d.Type {
+		case diffmp.Noop:
+			for k := 0; k < n; k++ {
+				ret[j+k] = i + k
+			}
+			i += n
+			j += n
+		case diffmp.Delete:
+			i += n
+		case diffmp.Insert:
+			j += n
+		}
+	}
+	return ret
+}
+
+// Blame finds which revision introduced each line of the last revision.
+// It pushes the origin of lines forward through the diffs between
+// successive revisions. It returns nil if there are no revisions.
+func Blame(revs []*Revision, opts *BlameOptions) []*BlameLine {
+	if opts == nil {
+		opts = new(BlameOptions)
+	}
+	match := matchLines(matcherLines)
+	if opts.LineMode {
+		match = lineModeLines
+	}
+	var key func(string) string
+	if opts.IgnoreSpace {
+		key = IgnoreAllSpace
+	}
+
+	var blame []*BlameLine
+	var prev []string
+	for r, rev := range revs {
+		cur := lineKeys(rev.Lines, key)
+		m := match(prev, cur)
+		next := make([]*BlameLine, len(cur))
+		for j, i := range m {
+			line := &BlameLine{Text: rev.Lines[j], Rev: r, Line: j + 1}
+			if i >= 0 {
+				line.Rev = blame[i].Rev
+				line.Line = blame[i].Line
+			}
+			next[j] = line
+		}
+		blame = next
+		prev = cur
+	}
+	return blame
+}
@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"testing"
+)
+
+type blameWant struct {
+	rev, line int
+}
+
+func checkBlame(t *testing.T, got []*BlameLine, want []blameWant) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].Rev != w.rev || got[i].Line != w.line {
+			t.Errorf(
+				"line %d: got rev %d line %d, want rev %d line %d",
+				i+1, got[i].Rev, got[i].Line, w.rev, w.line,
+			)
+		}
+	}
+}
+
+func blameRevs(texts ...string) []*Revision {
+	var revs []*Revision
+	for _, text := range texts {
+		revs = append(revs, &Revision{Lines: SplitLines(text)})
+	}
+	return revs
+}
+
+func TestBlame(t *testing.T) {
+	revs := blameRevs(
+		"one\ntwo\nthree",
+		"zero\none\ntwo\nthree",
+		"zero\none\n  two  \nthree\nfour",
+		"zero\nTWO\nthree\nfour",
+	)
+	for _, lineMode := range []bool{false, true} {
+		got := Blame(revs, &BlameOptions{LineMode: lineMode})
+		checkBlame(t, got, []blameWant{
+			{1, 1}, {3, 2}, {0, 3}, {2, 5},
+		})
+		assertEqual(t, got[1].Text, "TWO\n")
+
+		got = Blame(revs[:3], &BlameOptions{
+			LineMode:    lineMode,
+			IgnoreSpace: true,
+		})
+		checkBlame(t, got, []blameWant{
+			{1, 1}, {0, 1}, {0, 2}, {0, 3}, {2, 5},
+		})
+		assertEqual(t, got[2].Text, "  two  \n")
+	}
+
+	assertEqual(t, len(Blame(nil, nil)), 0)
+}
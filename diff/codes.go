@@ -0,0 +1,82 @@
+package diff
+
+import (
+	"strings"
+)
+
+func lineKeys(lines []string, key func(string) string) []string {
+	if key == nil {
+		return lines
+	}
+	ret := make([]string, len(lines))
+	for i, line := range lines {
+		ret[i] = key(line)
+	}
+	return ret
+}
+
+func inputMatcher(in *Input) *SequenceMatcher {
+	a := lineKeys(in.A.Lines, in.Key)
+	b := lineKeys(in.B.Lines, in.Key)
+	return NewMatcher(a, b)
+}
+
+func allBlank(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ignorable checks if an op code only changes blank lines.
+func ignorable(in *Input, c OpCode) bool {
+	if !in.IgnoreBlankLines || c.Tag == 'e' {
+		return false
+	}
+	return allBlank(in.A.Lines[c.I1:c.I2]) &&
+		allBlank(in.B.Lines[c.J1:c.J2])
+}
+
+// inputOpCodes returns the op codes of an input, without the changes that
+// are ignored.
+func inputOpCodes(in *Input) []OpCode {
+	var ret []OpCode
+	for _, c := range inputMatcher(in).OpCodes() {
+		if !ignorable(in, c) {
+			ret = append(ret, c)
+		}
+	}
+	return ret
+}
+
+// inputGroupedOpCodes returns the grouped op codes of an input, without the
+// groups that only have ignored changes.
+func inputGroupedOpCodes(in *Input) [][]OpCode {
+	groups := inputMatcher(in).GroupedOpCodes(in.Context)
+	var ret [][]OpCode
+	for _, g := range groups {
+		for _, c := range g {
+			if c.Tag != 'e' && !ignorable(in, c) {
+				ret = append(ret, g)
+				break
+			}
+		}
+	}
+	return ret
+}
+
+// Differs checks if the two files of the input are different, taking
+// Key and IgnoreBlankLines into account.
+func Differs(in *Input) bool {
+	if isBinary, differs := binaryDiffers(in); isBinary {
+		return differs
+	}
+	for _, c := range inputOpCodes(in) {
+		if c.Tag != 'e' {
+			return true
+		}
+	}
+	return false
+}
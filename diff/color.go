@@ -0,0 +1,114 @@
+package diff
+
+import (
+	"strings"
+)
+
+// Colors are ANSI escape sequences for coloring diff output.
+type Colors struct {
+	Header string // File headers
+	Hunk   string // Hunk headers
+	Delete string // Deleted lines
+	Insert string // Inserted lines
+
+	// DeleteSpan and InsertSpan color the changed characters within
+	// deleted and inserted lines, when Input.Intraline is set.
+	DeleteSpan string
+	InsertSpan string
+}
+
+// DefaultColors are the colors that git uses by default.
+var DefaultColors = &Colors{
+	Header: "\x1b[1m",
+	Hunk:   "\x1b[36m",
+	Delete: "\x1b[31m",
+	Insert: "\x1b[32m",
+
+	DeleteSpan: "\x1b[7;31m",
+	InsertSpan: "\x1b[7;32m",
+}
+
+const colorReset = "\x1b[m"
+
+func (c *Colors) header() string {
+	if c == nil {
+		return ""
+	}
+	return c.Header
+}
+
+func (c *Colors) hunk() string {
+	if c == nil {
+		return ""
+	}
+	return c.Hunk
+}
+
+func (c *Colors) delete() string {
+	if c == nil {
+		return ""
+	}
+	return c.Delete
+}
+
+func (c *Colors) insert() string {
+	if c == nil {
+		return ""
+	}
+	return c.Insert
+}
+
+func (c *Colors) deleteSpan() string {
+	if c == nil {
+		return ""
+	}
+	return c.DeleteSpan
+}
+
+func (c *Colors) insertSpan() string {
+	if c == nil {
+		return ""
+	}
+	return c.InsertSpan
+}
+
+// paint colors s up to its first line ending. The line ending and anything
+// after it, such as a "No newline" marker, are left as is.
+func paint(color, s string) string {
+	if color == "" {
+		return s
+	}
+	end := strings.IndexAny(s, "\r\n")
+	if end < 0 {
+		end = len(s)
+	}
+	return color + s[:end] + colorReset + s[end:]
+}
+
+// paintSpans works like paint on prefix+line, and also colors the spans of
+// line with spanColor. The spans are byte offsets in line.
+func paintSpans(color, spanColor, prefix, line string, spans []Span) string {
+	if color == "" || spanColor == "" || len(spans) == 0 {
+		return paint(color, prefix+line)
+	}
+	end := strings.IndexAny(line, "\r\n")
+	if end < 0 {
+		end = len(line)
+	}
+
+	var b strings.Builder
+	b.WriteString(color + prefix)
+	pos := 0
+	for _, s := range spans {
+		if s.Start >= end {
+			break
+		}
+		e := min(s.End, end)
+		b.WriteString(line[pos:s.Start])
+		b.WriteString(colorReset + spanColor + line[s.Start:e])
+		b.WriteString(colorReset + color)
+		pos = e
+	}
+	b.WriteString(line[pos:end] + colorReset + line[end:])
+	return b.String()
+}
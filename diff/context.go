@@ -24,14 +24,11 @@ import (
 // diff.FromFile, diff.ToFile, diff.FromDate, diff.ToDate.  The modification
 // times are normally expressed in the ISO 8601 format.  If not specified, the
 // strings default to blanks.
+//
+// If either file is binary, only a "Binary files ... differ" line is
+// written, like GNU diff does.
 func WriteContextDiff(writer io.Writer, in *Input) error {
 	var diffErr error
-	wf := func(format string, args ...interface{}) {
-		_, err := fmt.Fprintf(writer, format, args...)
-		if diffErr == nil && err != nil {
-			diffErr = err
-		}
-	}
 	ws := func(s string) {
 		_, err := fmt.Fprint(writer, s)
 		if diffErr == nil && err != nil {
@@ -43,6 +40,13 @@ func WriteContextDiff(writer io.Writer, in *Input) error {
 		in.Eol = "\n"
 	}
 
+	if isBinary, differs := binaryDiffers(in); isBinary {
+		if differs {
+			return writeBinaryDiffers(writer, in)
+		}
+		return nil
+	}
+
 	prefix := map[byte]string{
 		'i': "+ ",
 		'd': "- ",
@@ -50,27 +54,38 @@ func WriteContextDiff(writer io.Writer, in *Input) error {
 		'e': "  ",
 	}
 
-	m := NewMatcher(in.A.Lines, in.B.Lines)
-	codes := m.GroupedOpCodes(in.Context)
+	codes := inputGroupedOpCodes(in)
+	colors := in.Colors
+	var spans *lineSpans
+	if colors != nil {
+		spans = inputSpans(in, codes)
+	}
 	if len(codes) > 0 && (in.A.Name != "" || in.B.Name != "") {
-		wf("*** %s%s", in.A.title(), in.Eol)
-		wf("--- %s%s", in.B.title(), in.Eol)
+		ws(paint(colors.header(), "*** "+in.A.title()+in.Eol))
+		ws(paint(colors.header(), "--- "+in.B.title()+in.Eol))
 	}
 	for _, g := range codes {
 
 		first, last := g[0], g[len(g)-1]
-		ws("***************" + in.Eol)
+		ws(paint(colors.hunk(), "***************"+in.Eol))
 
 		range1 := formatRangeContext(first.I1, last.I2)
-		wf("*** %s ****%s", range1, in.Eol)
+		ws(paint(colors.hunk(), "*** "+range1+" ****"+in.Eol))
 		for _, c := range g {
 			if c.Tag == 'r' || c.Tag == 'd' {
 				for _, cc := range g {
 					if cc.Tag == 'i' {
 						continue
 					}
-					for _, line := range in.A.slice(cc.I1, cc.I2) {
-						ws(prefix[cc.Tag] + line)
+					color := ""
+					if cc.Tag != 'e' {
+						color = colors.delete()
+					}
+					for k, line := range in.A.outLines(cc.I1, cc.I2) {
+						ws(paintSpans(
+							color, colors.deleteSpan(), prefix[cc.Tag],
+							line, spans.spansA(cc.I1+k),
+						))
 					}
 				}
 				break
@@ -78,7 +93,7 @@ func WriteContextDiff(writer io.Writer, in *Input) error {
 		}
 
 		range2 := formatRangeContext(first.J1, last.J2)
-		wf("--- %s ----%s", range2, in.Eol)
+		ws(paint(colors.hunk(), "--- "+range2+" ----"+in.Eol))
 		for _, c := range g {
 			if c.Tag == 'r' || c.Tag == 'i' {
 				for _, cc := range g {
@@ -86,8 +101,15 @@ func WriteContextDiff(writer io.Writer, in *Input) error {
 					if cc.Tag == 'd' {
 						continue
 					}
-					for _, line := range in.B.slice(cc.J1, cc.J2) {
-						ws(prefix[cc.Tag] + line)
+					color := ""
+					if cc.Tag != 'e' {
+						color = colors.insert()
+					}
+					for k, line := range in.B.outLines(cc.J1, cc.J2) {
+						ws(paintSpans(
+							color, colors.insertSpan(), prefix[cc.Tag],
+							line, spans.spansB(cc.J1+k),
+						))
 					}
 				}
 				break
@@ -0,0 +1,170 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"shanhu.io/third/diffmp"
+)
+
+// Edit is an edit of a diff from either diff engine: an OpCode of a
+// SequenceMatcher with the lines it covers, or a diffmp.Diff. Renderers,
+// statistics and appliers that take Edits work with both engines.
+type Edit interface {
+	// Tag is 'e' for equal text, 'd' for a deletion, 'i' for an insertion
+	// and 'r' for a replacement, like the Tag of an OpCode.
+	Tag() byte
+
+	// Old is the text of the edit in the old text, and New is the text in
+	// the new text. They are the same for equal text.
+	Old() string
+	New() string
+}
+
+type opCodeEdit struct {
+	tag      byte
+	old, new string
+}
+
+func (e *opCodeEdit) Tag() byte   { return e.tag }
+func (e *opCodeEdit) Old() string { return e.old }
+func (e *opCodeEdit) New() string { return e.new }
+
+// OpCodeEdits converts op codes of sequences a and b to edits.
+func OpCodeEdits(codes []OpCode, a, b []string) []Edit {
+	ret := make([]Edit, 0, len(codes))
+	for _, c := range codes {
+		ret = append(ret, &opCodeEdit{
+			tag: c.Tag,
+			old: strings.Join(a[c.I1:c.I2], ""),
+			new: strings.Join(b[c.J1:c.J2], ""),
+		})
+	}
+	return ret
+}
+
+type diffEdit struct {
+	diffmp.Diff
+}
+
+func (e *diffEdit) Tag() byte {
+	switch e.Type {
+	case diffmp.Delete:
+		return 'd'
+	case diffmp.Insert:
+		return 'i'
+	}
+	return 'e'
+}
+
+func (e *diffEdit) Old() string {
+	if e.Type == diffmp.Insert {
+		return ""
+	}
+	return e.Text
+}
+
+func (e *diffEdit) New() string {
+	if e.Type == diffmp.Delete {
+		return ""
+	}
+	return e.Text
+}
+
+// DiffEdits converts diffmp diffs to edits.
+func DiffEdits(diffs []diffmp.Diff) []Edit {
+	ret := make([]Edit, 0, len(diffs))
+	for _, d := range diffs {
+		ret = append(ret, &diffEdit{d})
+	}
+	return ret
+}
+
+// OpCodesToDiffs converts op codes of sequences a and b to diffmp diffs.
+// A replacement becomes a deletion followed by an insertion.
+func OpCodesToDiffs(codes []OpCode, a, b []string) []diffmp.Diff {
+	var ret []diffmp.Diff
+	add := func(typ diffmp.Op, lines []string) {
+		if len(lines) > 0 {
+			ret = append(ret, diffmp.Diff{
+				Type: typ,
+				Text: strings.Join(lines, ""),
+			})
+		}
+	}
+	for _, c := range codes {
+		if c.Tag == 'e' {
+			add(diffmp.Noop, a[c.I1:c.I2])
+			continue
+		}
+		add(diffmp.Delete, a[c.I1:c.I2])
+		add(diffmp.Insert, b[c.J1:c.J2])
+	}
+	return ret
+}
+
+// countLines returns the number of lines of s, counting a last line that
+// does not end with "\n".
+func countLines(s string) int {
+	n := strings.Count(s, "\n")
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		n++
+	}
+	return n
+}
+
+// DiffsToOpCodes converts line-mode diffmp diffs to op codes, where lines
+// count with SplitLines. Each run of deletions and insertions between
+// equal text becomes one op code. It returns an error if a diff does not
+// end at the end of a line, other than at the end of a text.
+func DiffsToOpCodes(diffs []diffmp.Diff) ([]OpCode, error) {
+	var ret []OpCode
+	i, j := 0, 0
+	ndel, nins := 0, 0
+	flush := func() {
+		var tag byte
+		switch {
+		case ndel > 0 && nins > 0:
+			tag = 'r'
+		case ndel > 0:
+			tag = 'd'
+		case nins > 0:
+			tag = 'i'
+		default:
+			return
+		}
+		ret = append(ret, OpCode{tag, i, i + ndel, j, j + nins})
+		i += ndel
+		j += nins
+		ndel, nins = 0, 0
+	}
+
+	var partial1, partial2 bool // A text has ended in the middle of a line.
+	for k, d := range diffs {
+		if d.Text == "" {
+			continue
+		}
+		in1, in2 := d.Type != diffmp.Insert, d.Type != diffmp.Delete
+		if in1 && partial1 || in2 && partial2 {
+			return nil, fmt.Errorf("diff %d does not start a line", k)
+		}
+		partial := !strings.HasSuffix(d.Text, "\n")
+		partial1 = partial1 || in1 && partial
+		partial2 = partial2 || in2 && partial
+
+		n := countLines(d.Text)
+		switch d.Type {
+		case diffmp.Noop:
+			flush()
+			ret = append(ret, OpCode{'e', i, i + n, j, j + n})
+			i += n
+			j += n
+		case diffmp.Delete:
+			ndel += n
+		case diffmp.Insert:
+			nins += n
+		}
+	}
+	flush()
+	return ret, nil
+}
@@ -0,0 +1,55 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// EditStat is the statistics of a list of edits, like git diff --numstat
+// gives.
+type EditStat struct {
+	// Inserted and Deleted are the numbers of lines inserted and deleted.
+	// A replacement counts as both. A partial line counts as a line.
+	Inserted, Deleted int
+
+	// InsertedBytes and DeletedBytes are the lengths of the inserted and
+	// deleted text.
+	InsertedBytes, DeletedBytes int
+}
+
+// EditStats counts the changes of edits.
+func EditStats(edits []Edit) *EditStat {
+	s := new(EditStat)
+	for _, e := range edits {
+		if e.Tag() == 'e' {
+			continue
+		}
+		s.Deleted += countLines(e.Old())
+		s.Inserted += countLines(e.New())
+		s.DeletedBytes += len(e.Old())
+		s.InsertedBytes += len(e.New())
+	}
+	return s
+}
+
+// ApplyEdits applies edits to old and returns the new text. The edits
+// must cover all of old, in order.
+func ApplyEdits(old string, edits []Edit) (string, error) {
+	buf := new(bytes.Buffer)
+	pos := 0
+	for i, e := range edits {
+		o := e.Old()
+		if !strings.HasPrefix(old[pos:], o) {
+			return "", fmt.Errorf(
+				"edit %d does not match the text at %d", i, pos,
+			)
+		}
+		pos += len(o)
+		buf.WriteString(e.New())
+	}
+	if pos != len(old) {
+		return "", fmt.Errorf("edits end at %d of %d", pos, len(old))
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,74 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"shanhu.io/third/diffmp"
+)
+
+func TestEditConversions(t *testing.T) {
+	a := SplitLines("one\ntwo\nthree\nfour")
+	b := SplitLines("zero\none\nTWO\nthree")
+	codes := NewMatcher(a, b).OpCodes()
+	diffs := OpCodesToDiffs(codes, a, b)
+	assertEqual(t, diffmp.DiffText1(diffs), "one\ntwo\nthree\nfour\n")
+	assertEqual(t, diffmp.DiffText2(diffs), "zero\none\nTWO\nthree\n")
+
+	back, err := DiffsToOpCodes(diffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, back, codes)
+
+	diffs = []diffmp.Diff{
+		{Type: diffmp.Noop, Text: "a\n"},
+		{Type: diffmp.Delete, Text: "b\nc\n"},
+		{Type: diffmp.Insert, Text: "x\n"},
+		{Type: diffmp.Noop, Text: "d\n"},
+		{Type: diffmp.Insert, Text: "e"},
+	}
+	codes, err = DiffsToOpCodes(diffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, codes, []OpCode{
+		{'e', 0, 1, 0, 1},
+		{'r', 1, 3, 1, 2},
+		{'e', 3, 4, 2, 3},
+		{'i', 4, 4, 3, 4},
+	})
+
+	_, err = DiffsToOpCodes([]diffmp.Diff{
+		{Type: diffmp.Noop, Text: "a"},
+		{Type: diffmp.Delete, Text: "b\n"},
+	})
+	if err == nil {
+		t.Error("want error for a diff in the middle of a line")
+	}
+}
+
+func TestEdits(t *testing.T) {
+	a, b := SplitLines("one\ntwo\nthree"), SplitLines("one\n2\nthree\nfour")
+	text1, text2 := strings.Join(a, ""), strings.Join(b, "")
+	lineEdits := OpCodeEdits(NewMatcher(a, b).OpCodes(), a, b)
+	charEdits := DiffEdits(diffmp.New().DiffMain(text1, text2, false))
+
+	for _, edits := range [][]Edit{lineEdits, charEdits} {
+		got, err := ApplyEdits(text1, edits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, got, text2)
+
+		_, err = ApplyEdits(text2, edits)
+		if err == nil {
+			t.Error("want error for edits of another text")
+		}
+	}
+
+	assertEqual(t, EditStats(lineEdits), &EditStat{
+		Inserted: 2, Deleted: 1, InsertedBytes: 7, DeletedBytes: 4,
+	})
+	assertEqual(t, EditStats(charEdits).DeletedBytes, 3)
+}
@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is the character encoding of a text file.
+type Encoding int
+
+// Encodings that are detected by their byte order marks. Files without a
+// byte order mark are read as UTF-8.
+const (
+	UTF8 Encoding = iota
+	UTF8BOM
+	UTF16LE
+	UTF16BE
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case UTF8:
+		return "utf-8"
+	case UTF8BOM:
+		return "utf-8-bom"
+	case UTF16LE:
+		return "utf-16le"
+	case UTF16BE:
+		return "utf-16be"
+	}
+	return "unknown"
+}
+
+// detectEncoding detects the encoding of data by its byte order mark, and
+// returns the encoding and the content after the byte order mark.
+func detectEncoding(data []byte) (Encoding, []byte) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return UTF8BOM, data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return UTF16LE, data[len(bomUTF16LE):]
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return UTF16BE, data[len(bomUTF16BE):]
+	}
+	return UTF8, data
+}
+
+// decodeText decodes data that has its byte order mark removed into a
+// UTF-8 string.
+func decodeText(e Encoding, data []byte) (string, error) {
+	if e != UTF16LE && e != UTF16BE {
+		return string(data), nil
+	}
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("odd number of bytes in %s text", e)
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		b0, b1 := uint16(data[2*i]), uint16(data[2*i+1])
+		if e == UTF16LE {
+			units[i] = b1<<8 | b0
+		} else {
+			units[i] = b0<<8 | b1
+		}
+	}
+	return string(utf16.Decode(units)), nil
+}
+
+// encodeText encodes a UTF-8 string with the encoding, including the byte
+// order mark.
+func encodeText(e Encoding, s string) []byte {
+	switch e {
+	case UTF8BOM:
+		return append(append([]byte{}, bomUTF8...), s...)
+	case UTF16LE, UTF16BE:
+	default:
+		return []byte(s)
+	}
+
+	bom := bomUTF16LE
+	if e == UTF16BE {
+		bom = bomUTF16BE
+	}
+	ret := append([]byte{}, bom...)
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		for _, u := range utf16.Encode([]rune{r}) {
+			if e == UTF16LE {
+				ret = append(ret, byte(u), byte(u>>8))
+			} else {
+				ret = append(ret, byte(u>>8), byte(u))
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,64 @@
+package diff
+
+import (
+	"strings"
+)
+
+// Line endings.
+const (
+	LF   = "\n"
+	CRLF = "\r\n"
+	CR   = "\r"
+)
+
+// splitLinesEOL splits s into lines on LF, CRLF and lone CR. It returns
+// the lines with their line endings, and the line ending of each line.
+// The last line has an empty line ending if s does not end with one.
+// Unlike SplitLines, no empty line is added after a trailing line ending.
+func splitLinesEOL(s string) (lines, eols []string) {
+	for len(s) > 0 {
+		i := strings.IndexAny(s, "\r\n")
+		if i < 0 {
+			lines = append(lines, s)
+			eols = append(eols, "")
+			break
+		}
+		eol := LF
+		if s[i] == '\r' {
+			eol = CR
+			if i+1 < len(s) && s[i+1] == '\n' {
+				eol = CRLF
+			}
+		}
+		end := i + len(eol)
+		lines = append(lines, s[:end])
+		eols = append(eols, eol)
+		s = s[end:]
+	}
+	return lines, eols
+}
+
+// trimEOL removes the line ending of a line.
+func trimEOL(line string) string {
+	if strings.HasSuffix(line, CRLF) {
+		return line[:len(line)-len(CRLF)]
+	}
+	if strings.HasSuffix(line, LF) || strings.HasSuffix(line, CR) {
+		return line[:len(line)-1]
+	}
+	return line
+}
+
+// normalizeEOL converts the line ending of each line into LF. A line
+// without a line ending is left as is.
+func normalizeEOL(lines []string) []string {
+	ret := make([]string, len(lines))
+	for i, line := range lines {
+		body := trimEOL(line)
+		if body != line {
+			body += LF
+		}
+		ret[i] = body
+	}
+	return ret
+}
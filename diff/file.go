@@ -2,6 +2,7 @@ package diff
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -11,6 +12,25 @@ type File struct {
 	Lines   []string
 	Time    *time.Time
 	TimeStr string
+
+	// Data is the raw content of the file. It is only set when the file is
+	// created from bytes.
+	Data []byte
+
+	// Binary is true when the content is binary. A binary file has no
+	// lines and is compared as a whole.
+	Binary bool
+
+	// Eols is the original line ending of each line: LF, CRLF, CR, or
+	// empty for a last line that has no line ending. It is only set when
+	// the file is read from bytes. When set, diffs print each line with
+	// its original line ending, even if the line endings in Lines are
+	// normalized.
+	Eols []string
+
+	// Encoding is the encoding of the original content. Lines are always
+	// decoded into UTF-8.
+	Encoding Encoding
 }
 
 // NewStringFile create a file from a string.
@@ -21,8 +41,40 @@ func NewStringFile(name, s string) *File {
 	}
 }
 
-func (f *File) slice(from, to int) []string {
-	return f.Lines[from:to]
+// noNewline is the marker that follows a line without a line ending.
+const noNewline = "\n\\ No newline at end of file\n"
+
+// outLines returns lines in [from, to) as they are printed in a diff.
+func (f *File) outLines(from, to int) []string {
+	if f.Eols == nil {
+		return f.Lines[from:to]
+	}
+	ret := make([]string, 0, to-from)
+	for i := from; i < to; i++ {
+		eol := f.Eols[i]
+		if eol == "" {
+			eol = noNewline
+		}
+		ret = append(ret, trimEOL(f.Lines[i])+eol)
+	}
+	return ret
+}
+
+// Content returns the content of a text file with its original line
+// endings and encoding. For a binary file, it returns Data.
+func (f *File) Content() []byte {
+	if f.Binary {
+		return f.Data
+	}
+	if f.Eols == nil {
+		return encodeText(f.Encoding, strings.Join(f.Lines, ""))
+	}
+	var b strings.Builder
+	for i, line := range f.Lines {
+		b.WriteString(trimEOL(line))
+		b.WriteString(f.Eols[i])
+	}
+	return encodeText(f.Encoding, b.String())
 }
 
 func (f *File) title() string {
@@ -0,0 +1,114 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DevNull is the file name that patches use for a missing file.
+const DevNull = "/dev/null"
+
+// FilePatch is the change of one file in a patch.
+type FilePatch struct {
+	// OldName is the name of the file before the change, and NewName is
+	// the name after the change. Names are as they appear in the patch,
+	// with prefixes like "a/" and "b/". OldName is empty for a created
+	// file, and NewName is empty for a deleted file.
+	OldName, NewName string
+
+	// Modes and object names from git extended headers, if any.
+	OldMode, NewMode string
+	OldHash, NewHash string
+
+	IsRename bool // The file is renamed from OldName to NewName.
+	IsCopy   bool // The file is copied from OldName to NewName.
+
+	Hunks []*Hunk
+
+	// Binary is the binary patch of a binary file. BinaryNoData is true
+	// when the patch only says that the binary files differ.
+	Binary       *BinaryPatch
+	BinaryNoData bool
+}
+
+// IsNew checks if the patch creates the file.
+func (p *FilePatch) IsNew() bool { return p.OldName == "" }
+
+// IsDelete checks if the patch deletes the file.
+func (p *FilePatch) IsDelete() bool { return p.NewName == "" }
+
+// Name returns the name of the file being patched: the new name unless the
+// file is deleted.
+func (p *FilePatch) Name() string {
+	if p.NewName != "" {
+		return p.NewName
+	}
+	return p.OldName
+}
+
+// Reverse returns a file patch that undoes the change of p.
+func (p *FilePatch) Reverse() *FilePatch {
+	ret := &FilePatch{
+		OldName:      p.NewName,
+		NewName:      p.OldName,
+		OldMode:      p.NewMode,
+		NewMode:      p.OldMode,
+		OldHash:      p.NewHash,
+		NewHash:      p.OldHash,
+		IsRename:     p.IsRename,
+		IsCopy:       p.IsCopy,
+		BinaryNoData: p.BinaryNoData,
+	}
+	for _, h := range p.Hunks {
+		ret.Hunks = append(ret.Hunks, h.Reverse())
+	}
+	if p.Binary != nil {
+		ret.Binary = &BinaryPatch{
+			Forward: p.Binary.Reverse,
+			Reverse: p.Binary.Forward,
+		}
+	}
+	return ret
+}
+
+// ApplyBinary applies the binary patch on the old content. When the patch
+// has full object names, the old and the new content are verified.
+func (p *FilePatch) ApplyBinary(old []byte) ([]byte, error) {
+	if p.Binary == nil || p.Binary.Forward == nil {
+		return nil, fmt.Errorf("no binary patch data for %s", p.Name())
+	}
+	if len(p.OldHash) == len(gitNullHash) && !p.IsNew() {
+		if h := gitBlobHash(old); h != p.OldHash {
+			return nil, fmt.Errorf(
+				"%s: content is %s, want %s", p.Name(), h, p.OldHash,
+			)
+		}
+	}
+	ret, err := ApplyBinaryHunk(old, p.Binary.Forward)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.NewHash) == len(gitNullHash) && !p.IsDelete() {
+		if h := gitBlobHash(ret); h != p.NewHash {
+			return nil, fmt.Errorf(
+				"%s: patched content is %s, want %s",
+				p.Name(), h, p.NewHash,
+			)
+		}
+	}
+	return ret, nil
+}
+
+// StripPath removes n leading components from a slash separated path, like
+// the -p option of patch(1). It returns an empty string if the path does
+// not have more than n components.
+func StripPath(p string, n int) string {
+	for ; n > 0; n-- {
+		i := strings.IndexByte(p, '/')
+		if i < 0 {
+			return ""
+		}
+		p = strings.TrimLeft(p[i+1:], "/")
+	}
+	return p
+}
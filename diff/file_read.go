@@ -0,0 +1,106 @@
+package diff
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// ReadOptions are options for reading a file.
+type ReadOptions struct {
+	// NormalizeEOL converts CRLF and lone CR line endings into LF, so that
+	// files with different line endings can be compared line by line. The
+	// original line endings are kept in File.Eols.
+	NormalizeEOL bool
+
+	// ModTime captures the modification time into File.Time when it is
+	// available.
+	ModTime bool
+
+	// Sniffer classifies the content as text or binary. The default
+	// sniffer is used when nil.
+	Sniffer *Sniffer
+}
+
+// NewBytesFile creates a file from raw content with default options.
+func NewBytesFile(name string, data []byte) *File {
+	f, err := newBytesFile(name, data, nil)
+	if err != nil {
+		// Only malformed UTF-16 fails to decode; compare it as binary.
+		return &File{Name: name, Data: data, Binary: true}
+	}
+	return f
+}
+
+func newBytesFile(name string, data []byte, opts *ReadOptions) (
+	*File, error,
+) {
+	if opts == nil {
+		opts = new(ReadOptions)
+	}
+	sniffer := opts.Sniffer
+	if sniffer == nil {
+		sniffer = new(Sniffer)
+	}
+
+	f := &File{Name: name, Data: data}
+	if sniffer.Sniff(data) == Binary {
+		f.Binary = true
+		return f, nil
+	}
+
+	enc, body := detectEncoding(data)
+	s, err := decodeText(enc, body)
+	if err != nil {
+		return nil, err
+	}
+	f.Encoding = enc
+	f.Lines, f.Eols = splitLinesEOL(s)
+	if f.Lines == nil {
+		f.Lines = []string{}
+		f.Eols = []string{}
+	}
+	if opts.NormalizeEOL {
+		f.Lines = normalizeEOL(f.Lines)
+	}
+	return f, nil
+}
+
+// ReadFile creates a file by reading all content from r. The content is
+// sniffed for binary, decoded by its byte order mark, and split into lines
+// on LF, CRLF and lone CR. If ModTime is set in opts and r has a Stat()
+// method, like *os.File, the modification time is captured.
+func ReadFile(name string, r io.Reader, opts *ReadOptions) (*File, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := newBytesFile(name, data, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts != nil && opts.ModTime {
+		if st, ok := r.(interface {
+			Stat() (os.FileInfo, error)
+		}); ok {
+			info, err := st.Stat()
+			if err != nil {
+				return nil, err
+			}
+			t := info.ModTime()
+			f.Time = &t
+		}
+	}
+	return f, nil
+}
+
+// LoadFile creates a file by reading the file at path. The file is named
+// after the path.
+func LoadFile(path string, opts *ReadOptions) (*File, error) {
+	fin, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fin.Close()
+	return ReadFile(path, fin, opts)
+}
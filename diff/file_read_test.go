@@ -0,0 +1,103 @@
+package diff
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitLinesEOL(t *testing.T) {
+	lines, eols := splitLinesEOL("a\r\nb\rc\nd")
+	assertEqual(t, lines, []string{"a\r\n", "b\r", "c\n", "d"})
+	assertEqual(t, eols, []string{CRLF, CR, LF, ""})
+
+	lines, eols = splitLinesEOL("a\n")
+	assertEqual(t, lines, []string{"a\n"})
+	assertEqual(t, eols, []string{LF})
+}
+
+func TestReadFileNormalizeEOL(t *testing.T) {
+	opts := &ReadOptions{NormalizeEOL: true}
+	a, err := ReadFile("a", strings.NewReader("one\r\ntwo\r\n"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ReadFile("b", strings.NewReader("one\ntwo\nthree\n"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := UnifiedDiffString(&Input{A: a, B: b, Context: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a\n+++ b\n@@ -1,2 +1,3 @@\n" +
+		" one\r\n two\r\n+three\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if c := string(a.Content()); c != "one\r\ntwo\r\n" {
+		t.Errorf("content got %q", c)
+	}
+}
+
+func TestReadFileNoNewline(t *testing.T) {
+	a := NewBytesFile("a", []byte("x\ny"))
+	b := NewBytesFile("b", []byte("x\nz\n"))
+	got, err := UnifiedDiffString(&Input{A: a, B: b, Context: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n" +
+		"-y\n\\ No newline at end of file\n+z\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadFileUTF16(t *testing.T) {
+	data := []byte{0xff, 0xfe, 'h', 0, 'i', 0, '\r', 0, '\n', 0}
+	f, err := ReadFile("f", bytes.NewReader(data), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Binary || f.Encoding != UTF16LE {
+		t.Fatalf("got binary=%v encoding=%s", f.Binary, f.Encoding)
+	}
+	assertEqual(t, f.Lines, []string{"hi\r\n"})
+	if !bytes.Equal(f.Content(), data) {
+		t.Errorf("content got % x", f.Content())
+	}
+
+	_, err = ReadFile("f", bytes.NewReader(data[:len(data)-1]), nil)
+	if err == nil {
+		t.Errorf("odd length UTF-16 should fail")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := filepath.Join(dir, "f.txt")
+	if err := ioutil.WriteFile(p, []byte("a\rb\r"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := LoadFile(p, &ReadOptions{ModTime: true, NormalizeEOL: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, f.Lines, []string{"a\n", "b\n"})
+	assertEqual(t, f.Eols, []string{CR, CR})
+	if f.Time == nil {
+		t.Errorf("mod time not captured")
+	}
+	if f.Name != p {
+		t.Errorf("name got %q, want %q", f.Name, p)
+	}
+}
@@ -0,0 +1,102 @@
+package diff
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+)
+
+const (
+	gitFileMode = "100644"
+	gitNullHash = "0000000000000000000000000000000000000000"
+)
+
+// gitBlobHash returns the git object name of a blob with the given content.
+func gitBlobHash(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func fileData(f *File) []byte {
+	if f == nil {
+		return nil
+	}
+	return f.Data
+}
+
+// binaryDiffers checks if the input has a binary file, and if so, if the
+// two files have different content.
+func binaryDiffers(in *Input) (isBinary, differs bool) {
+	if !in.A.Binary && !in.B.Binary {
+		return false, false
+	}
+	return true, !bytes.Equal(in.A.Data, in.B.Data)
+}
+
+// writeBinaryDiffers writes the one line notice that GNU diff prints when
+// two binary files differ.
+func writeBinaryDiffers(w io.Writer, in *Input) error {
+	_, err := fmt.Fprintf(
+		w, "Binary files %s and %s differ%s", in.A.Name, in.B.Name, in.Eol,
+	)
+	return err
+}
+
+// WriteGitBinaryDiff writes a git style diff for two binary files, with a
+// "GIT binary patch" section that carries the full change. The files'
+// names are prefixed with "a/" and "b/" in the header. A nil A means
+// the file is created, and a nil B means the file is deleted. The index
+// line has full object names so that the patch can be verified when
+// applied. Nothing is written when the files have the same content.
+func WriteGitBinaryDiff(w io.Writer, in *Input) error {
+	if in.A == nil && in.B == nil {
+		return fmt.Errorf("both files are missing")
+	}
+	a, b := fileData(in.A), fileData(in.B)
+	if in.A != nil && in.B != nil && bytes.Equal(a, b) {
+		return nil
+	}
+
+	nameA, nameB := "", ""
+	if in.A != nil {
+		nameA = in.A.Name
+	}
+	if in.B != nil {
+		nameB = in.B.Name
+	}
+	if nameA == "" {
+		nameA = nameB
+	}
+	if nameB == "" {
+		nameB = nameA
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "diff --git a/%s b/%s\n", nameA, nameB)
+	hashA, hashB := gitBlobHash(a), gitBlobHash(b)
+	mode := " " + gitFileMode
+	if in.A == nil {
+		fmt.Fprintf(buf, "new file mode %s\n", gitFileMode)
+		hashA, mode = gitNullHash, ""
+	} else if in.B == nil {
+		fmt.Fprintf(buf, "deleted file mode %s\n", gitFileMode)
+		hashB, mode = gitNullHash, ""
+	}
+	fmt.Fprintf(buf, "index %s..%s%s\n", hashA, hashB, mode)
+	if err := WriteBinaryPatch(buf, MakeBinaryPatch(a, b)); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// GitBinaryDiffString works like WriteGitBinaryDiff but returns the diff as
+// a string.
+func GitBinaryDiffString(in *Input) (string, error) {
+	buf := new(bytes.Buffer)
+	err := WriteGitBinaryDiff(buf, in)
+	return buf.String(), err
+}
@@ -0,0 +1,215 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Git delta format, as used in packfiles and in binary patches.
+//
+// A delta starts with the source size and the target size, each as a
+// little-endian base-128 varint. Then follows a list of instructions:
+//
+// - A copy instruction has the high bit set. Bits 0-3 tell which bytes of a
+// 32-bit source offset follow, and bits 4-6 tell which bytes of a 24-bit
+// size follow. A size of 0 means 0x10000.
+// - An insert instruction is a byte n in 1 to 127, followed by n literal
+// bytes to append to the target.
+
+const (
+	deltaBlockSize = 16       // size of source blocks being indexed
+	deltaMaxCopy   = 0xffffff // largest size of one copy instruction
+	deltaMaxInsert = 0x7f     // largest size of one insert instruction
+	deltaMaxOffset = 0xffffffff
+	deltaMaxVarint = 10 // largest length of a size header
+
+	// deltaMaxAlloc caps the memory reserved up front for a result, as
+	// the target size of a delta is not trusted.
+	deltaMaxAlloc = 1 << 20
+)
+
+func appendDeltaVarint(buf *bytes.Buffer, n int) {
+	for n >= 0x80 {
+		buf.WriteByte(byte(n) | 0x80)
+		n >>= 7
+	}
+	buf.WriteByte(byte(n))
+}
+
+// readDeltaVarint reads a size header. Sizes that do not fit in 63 bits,
+// or in an int, are rejected.
+func readDeltaVarint(d []byte) (int, []byte, error) {
+	var n uint64
+	for i := 0; i < deltaMaxVarint; i++ {
+		if i >= len(d) {
+			break
+		}
+		b := d[i]
+		v := uint64(b & 0x7f)
+		shift := uint(7 * i)
+		if shift+7 > 63 && v>>(63-shift) != 0 {
+			return 0, nil, fmt.Errorf("delta: size header overflows")
+		}
+		n |= v << shift
+		if b&0x80 == 0 {
+			const maxInt = int(^uint(0) >> 1)
+			if n > uint64(maxInt) {
+				return 0, nil, fmt.Errorf("delta: size %d too large", n)
+			}
+			return int(n), d[i+1:], nil
+		}
+	}
+	return 0, nil, fmt.Errorf("delta: bad size header")
+}
+
+func appendDeltaInsert(buf *bytes.Buffer, lit []byte) {
+	for len(lit) > 0 {
+		n := min(len(lit), deltaMaxInsert)
+		buf.WriteByte(byte(n))
+		buf.Write(lit[:n])
+		lit = lit[n:]
+	}
+}
+
+func appendDeltaCopy(buf *bytes.Buffer, off, size int) {
+	for size > 0 {
+		n := min(size, deltaMaxCopy)
+		op := byte(0x80)
+		var args []byte
+		for i := uint(0); i < 4; i++ {
+			if b := byte(off >> (8 * i)); b != 0 {
+				op |= 1 << i
+				args = append(args, b)
+			}
+		}
+		for i := uint(0); i < 3; i++ {
+			if b := byte(n >> (8 * i)); b != 0 {
+				op |= 1 << (4 + i)
+				args = append(args, b)
+			}
+		}
+		buf.WriteByte(op)
+		buf.Write(args)
+		off += n
+		size -= n
+	}
+}
+
+// makeDelta creates a git delta that turns src into dst. It indexes
+// the source in fixed-size blocks and greedily extends block matches found
+// in the target.
+func makeDelta(src, dst []byte) []byte {
+	buf := new(bytes.Buffer)
+	appendDeltaVarint(buf, len(src))
+	appendDeltaVarint(buf, len(dst))
+
+	index := make(map[string]int)
+	if int64(len(src)) <= deltaMaxOffset {
+		for i := 0; i+deltaBlockSize <= len(src); i += deltaBlockSize {
+			k := string(src[i : i+deltaBlockSize])
+			if _, found := index[k]; !found {
+				index[k] = i
+			}
+		}
+	}
+
+	lit := 0 // start of pending literal bytes in dst
+	i := 0
+	for i+deltaBlockSize <= len(dst) {
+		off, found := index[string(dst[i:i+deltaBlockSize])]
+		if !found {
+			i++
+			continue
+		}
+
+		// Extend the match backwards into pending literals, and forwards.
+		start := i
+		for start > lit && off > 0 && src[off-1] == dst[start-1] {
+			start--
+			off--
+		}
+		end := i + deltaBlockSize
+		for end < len(dst) && off+end-start < len(src) &&
+			src[off+end-start] == dst[end] {
+			end++
+		}
+
+		appendDeltaInsert(buf, dst[lit:start])
+		appendDeltaCopy(buf, off, end-start)
+		lit = end
+		i = end
+	}
+	appendDeltaInsert(buf, dst[lit:])
+	return buf.Bytes()
+}
+
+// applyDelta applies a git delta on src.
+func applyDelta(src, delta []byte) ([]byte, error) {
+	srcSize, d, err := readDeltaVarint(delta)
+	if err != nil {
+		return nil, err
+	}
+	if srcSize != len(src) {
+		return nil, fmt.Errorf(
+			"delta: source size %d, want %d", len(src), srcSize,
+		)
+	}
+	dstSize, d, err := readDeltaVarint(d)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]byte, 0, min(dstSize, deltaMaxAlloc))
+	for len(d) > 0 {
+		op := d[0]
+		d = d[1:]
+		if op == 0 {
+			return nil, fmt.Errorf("delta: reserved instruction 0")
+		}
+		if op&0x80 == 0 {
+			n := int(op)
+			if n > len(d) {
+				return nil, fmt.Errorf("delta: truncated insert")
+			}
+			if n > dstSize-len(ret) {
+				return nil, fmt.Errorf("delta: result larger than %d", dstSize)
+			}
+			ret = append(ret, d[:n]...)
+			d = d[n:]
+			continue
+		}
+
+		off, size := 0, 0
+		for i := uint(0); i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(d) == 0 {
+				return nil, fmt.Errorf("delta: truncated copy")
+			}
+			if i < 4 {
+				off |= int(d[0]) << (8 * i)
+			} else {
+				size |= int(d[0]) << (8 * (i - 4))
+			}
+			d = d[1:]
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if off+size > len(src) {
+			return nil, fmt.Errorf("delta: copy out of source range")
+		}
+		if size > dstSize-len(ret) {
+			return nil, fmt.Errorf("delta: result larger than %d", dstSize)
+		}
+		ret = append(ret, src[off:off+size]...)
+	}
+
+	if len(ret) != dstSize {
+		return nil, fmt.Errorf(
+			"delta: result size %d, want %d", len(ret), dstSize,
+		)
+	}
+	return ret, nil
+}
@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// htmlLineClass is the class of each kind of line in HTML output.
+var htmlLineClass = map[string]string{
+	" ": "ctx",
+	"-": "del",
+	"+": "ins",
+}
+
+func htmlLineNum(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprint(n)
+}
+
+// htmlSpans escapes text, and wraps the spans in tag.
+func htmlSpans(text, tag string, spans []Span) string {
+	var b strings.Builder
+	pos := 0
+	for _, s := range spans {
+		if s.Start >= len(text) {
+			break
+		}
+		e := min(s.End, len(text))
+		b.WriteString(html.EscapeString(text[pos:s.Start]))
+		fmt.Fprintf(
+			&b, "<%s>%s</%s>", tag, html.EscapeString(text[s.Start:e]), tag,
+		)
+		pos = e
+	}
+	b.WriteString(html.EscapeString(text[pos:]))
+	return b.String()
+}
+
+// WriteHTMLDiff writes the diff of the input as an HTML table, with the same
+// hunks as WriteUnifiedDiff. Each line is a row with the old and the new
+// line numbers and the line text. Rows have class "hunk", "ctx", "del" or
+// "ins". When in.Intraline is set, changed characters are wrapped in <del>
+// and <ins> elements. The table has no styles; use CSS to style it, for
+// example, with "white-space: pre" on the "text" cells.
+func WriteHTMLDiff(w io.Writer, in *Input) error {
+	d := MakeJSONDiff(in)
+	buf := new(bytes.Buffer)
+	buf.WriteString(`<table class="diff">` + "\n")
+	fmt.Fprintf(
+		buf, `<tr class="file"><th colspan="3">%s &rarr; %s</th></tr>`+"\n",
+		html.EscapeString(d.OldName), html.EscapeString(d.NewName),
+	)
+	if d.Binary {
+		buf.WriteString(
+			`<tr class="binary"><td colspan="3">` +
+				"Binary files differ</td></tr>\n",
+		)
+	}
+	for _, h := range d.Hunks {
+		fmt.Fprintf(
+			buf, `<tr class="hunk"><td colspan="3">@@ -%s +%s @@</td></tr>`,
+			formatHunkRange(h.OldStart, h.OldLines),
+			formatHunkRange(h.NewStart, h.NewLines),
+		)
+		buf.WriteString("\n")
+		for _, line := range h.Lines {
+			tag := "del"
+			if line.Op == "+" {
+				tag = "ins"
+			}
+			fmt.Fprintf(
+				buf, `<tr class="%s"><td class="num">%s</td>`+
+					`<td class="num">%s</td><td class="text">%s%s</td></tr>`,
+				htmlLineClass[line.Op],
+				htmlLineNum(line.OldLine), htmlLineNum(line.NewLine),
+				line.Op, htmlSpans(line.Text, tag, line.Spans),
+			)
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString("</table>\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// HTMLDiffString works like WriteHTMLDiff but returns the diff as a string.
+func HTMLDiffString(in *Input) (string, error) {
+	buf := new(bytes.Buffer)
+	err := WriteHTMLDiff(buf, in)
+	return buf.String(), err
+}
@@ -0,0 +1,115 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Hunk is a hunk of a unified diff.
+type Hunk struct {
+	// Start lines and line counts, as in the "@@ -1,3 +1,4 @@" header.
+	// Start lines are 1-based. When a count is 0, the start line is the
+	// line before the empty range.
+	OldStart, OldLines int
+	NewStart, NewLines int
+
+	// Section is the text after the closing "@@" of the header, often the
+	// enclosing function.
+	Section string
+
+	// Lines are the lines of the hunk, each starting with ' ', '-' or '+'
+	// and ending with its line ending. A line that was followed by a
+	// "\ No newline at end of file" marker has no line ending.
+	Lines []string
+}
+
+// oldIndex returns the 0-based index of the first line of the old side.
+func (h *Hunk) oldIndex() int {
+	if h.OldLines == 0 {
+		return h.OldStart
+	}
+	return h.OldStart - 1
+}
+
+// side returns the lines of one side of the hunk without the prefixes.
+// The old side has context and deleted lines; the new side has context
+// and inserted lines.
+func (h *Hunk) side(newSide bool) []string {
+	skip := byte('+')
+	if newSide {
+		skip = '-'
+	}
+	var ret []string
+	for _, line := range h.Lines {
+		if line[0] != skip {
+			ret = append(ret, line[1:])
+		}
+	}
+	return ret
+}
+
+// contextLen returns the number of leading and trailing context lines.
+func (h *Hunk) contextLen() (lead, trail int) {
+	for lead < len(h.Lines) && h.Lines[lead][0] == ' ' {
+		lead++
+	}
+	for trail < len(h.Lines)-lead &&
+		h.Lines[len(h.Lines)-1-trail][0] == ' ' {
+		trail++
+	}
+	return lead, trail
+}
+
+// Reverse returns a hunk that undoes the change of h.
+func (h *Hunk) Reverse() *Hunk {
+	ret := &Hunk{
+		OldStart: h.NewStart,
+		OldLines: h.NewLines,
+		NewStart: h.OldStart,
+		NewLines: h.OldLines,
+		Section:  h.Section,
+		Lines:    make([]string, len(h.Lines)),
+	}
+	for i, line := range h.Lines {
+		switch line[0] {
+		case '+':
+			line = "-" + line[1:]
+		case '-':
+			line = "+" + line[1:]
+		}
+		ret.Lines[i] = line
+	}
+	return ret
+}
+
+func formatHunkRange(start, n int) string {
+	if n == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+// WriteHunks writes hunks in the unified diff format.
+func WriteHunks(w io.Writer, hunks []*Hunk) error {
+	for _, h := range hunks {
+		_, err := fmt.Fprintf(
+			w, "@@ -%s +%s @@%s\n",
+			formatHunkRange(h.OldStart, h.OldLines),
+			formatHunkRange(h.NewStart, h.NewLines),
+			h.Section,
+		)
+		if err != nil {
+			return err
+		}
+		for _, line := range h.Lines {
+			if !strings.HasSuffix(line, "\n") {
+				line += noNewline
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -5,4 +5,24 @@ type Input struct {
 	A, B    *File
 	Eol     string // Headers end of line, defaults to LF
 	Context int    // Number of context lines
+
+	// Key, when not nil, maps each line to the key that is compared in
+	// place of the line, so that lines with the same key are equal.
+	// Changes are still printed with the original lines.
+	Key func(line string) string
+
+	// IgnoreBlankLines ignores hunks whose changes only insert or delete
+	// blank lines.
+	IgnoreBlankLines bool
+
+	// Width is the width of side-by-side output, defaults to 130.
+	Width int
+
+	// Colors, when not nil, colors the output with ANSI escape sequences.
+	Colors *Colors
+
+	// Intraline pairs similar lines in each replaced block, and marks the
+	// changed characters within the pairs in colored, HTML and JSON
+	// output.
+	Intraline bool
 }
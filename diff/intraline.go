@@ -0,0 +1,160 @@
+package diff
+
+import (
+	"shanhu.io/third/diffmp"
+)
+
+// Span is a range of bytes in a line, from Start to End.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+const (
+	// intralineMinSimilarity is how similar a deleted line and an
+	// inserted line must be to be paired. Less similar lines are shown as
+	// changed as a whole.
+	intralineMinSimilarity = 0.5
+
+	// intralineMaxSearch limits the number of line pairs that are diffed
+	// to find the best pairs in a replaced block. Larger blocks pair lines
+	// by their positions.
+	intralineMaxSearch = 2500
+)
+
+func appendSpan(spans []Span, start, end int) []Span {
+	if n := len(spans); n > 0 && spans[n-1].End == start {
+		spans[n-1].End = end
+		return spans
+	}
+	return append(spans, Span{Start: start, End: end})
+}
+
+// lineCharDiff finds the changed characters between two lines.
+func lineCharDiff(a, b string) []diffmp.Diff {
+	dmp := diffmp.New()
+	return diffmp.DiffCleanupSemantic(dmp.DiffMain(a, b, false))
+}
+
+// lineSimilarity is 1 for equal lines, and 0 for lines that have nothing
+// in common.
+func lineSimilarity(diffs []diffmp.Diff, a, b string) float64 {
+	n := max(len(a), len(b))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(diffmp.DiffLevenshtein(diffs))/float64(n)
+}
+
+// charDiffSpans returns the deleted spans in the first text and the
+// inserted spans in the second text of a character diff.
+func charDiffSpans(diffs []diffmp.Diff) (a, b []Span) {
+	i, j := 0, 0
+	for _, d := range diffs {
+		n := len(d.Text)
+		switch d.Type {
+		case diffmp.Delete:
+			a = appendSpan(a, i, i+n)
+			i += n
+		case diffmp.Insert:
+			b = appendSpan(b, j, j+n)
+			j += n
+		default:
+			i += n
+			j += n
+		}
+	}
+	return a, b
+}
+
+// pairSpans pairs similar lines between a block of deleted lines and the
+// block of inserted lines that replaces it, keeping their order, and
+// finds the changed spans in each pair. Lines have no line endings. The
+// spans are keyed by line indexes in old and cur; unpaired lines have no
+// spans.
+func pairSpans(old, cur []string) (a, b map[int][]Span) {
+	a = make(map[int][]Span)
+	b = make(map[int][]Span)
+	search := len(old)*len(cur) <= intralineMaxSearch
+	next := 0
+	for i, s := range old {
+		lo, hi := next, len(cur)
+		if !search {
+			lo, hi = max(i, next), min(i+1, len(cur))
+		}
+		best := -1
+		bestSim := intralineMinSimilarity
+		var bestDiffs []diffmp.Diff
+		for j := lo; j < hi; j++ {
+			diffs := lineCharDiff(s, cur[j])
+			sim := lineSimilarity(diffs, s, cur[j])
+			if sim > bestSim || (best < 0 && sim == bestSim) {
+				best, bestSim, bestDiffs = j, sim, diffs
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		a[i], b[best] = charDiffSpans(bestDiffs)
+		next = best + 1
+	}
+	return a, b
+}
+
+// lineSpans are the changed spans of lines in the two files, keyed by line
+// indexes.
+type lineSpans struct {
+	a, b map[int][]Span
+}
+
+func trimLines(lines []string) []string {
+	ret := make([]string, len(lines))
+	for i, line := range lines {
+		ret[i] = trimEOL(line)
+	}
+	return ret
+}
+
+// inputSpans finds the changed spans of the replaced lines in the groups.
+// It returns nil if in.Intraline is not set.
+func inputSpans(in *Input, groups [][]OpCode) *lineSpans {
+	if !in.Intraline {
+		return nil
+	}
+	ret := &lineSpans{
+		a: make(map[int][]Span),
+		b: make(map[int][]Span),
+	}
+	for _, g := range groups {
+		for _, c := range g {
+			if c.Tag != 'r' {
+				continue
+			}
+			a, b := pairSpans(
+				trimLines(in.A.Lines[c.I1:c.I2]),
+				trimLines(in.B.Lines[c.J1:c.J2]),
+			)
+			for i, spans := range a {
+				ret.a[c.I1+i] = spans
+			}
+			for j, spans := range b {
+				ret.b[c.J1+j] = spans
+			}
+		}
+	}
+	return ret
+}
+
+func (s *lineSpans) spansA(i int) []Span {
+	if s == nil {
+		return nil
+	}
+	return s.a[i]
+}
+
+func (s *lineSpans) spansB(j int) []Span {
+	if s == nil {
+		return nil
+	}
+	return s.b[j]
+}
@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPairSpans(t *testing.T) {
+	a, b := pairSpans(
+		[]string{"func f(a int) {", "totally different"},
+		[]string{"// comment", "func f(a, b int) {"},
+	)
+	assertEqual(t, len(a), 1)
+	assertEqual(t, len(b), 1)
+	assertEqual(t, a[0], []Span(nil))
+	assertEqual(t, b[1], []Span{{Start: 8, End: 11}})
+}
+
+func intralineInput() *Input {
+	return &Input{
+		A:         NewBytesFile("a", []byte("x\nvar n = 3\ny\n")),
+		B:         NewBytesFile("b", []byte("x\nvar n = 42\ny\n")),
+		Context:   1,
+		Intraline: true,
+	}
+}
+
+func TestUnifiedIntraline(t *testing.T) {
+	in := intralineInput()
+	in.Colors = &Colors{
+		Delete:     "<d>",
+		Insert:     "<i>",
+		DeleteSpan: "<D>",
+		InsertSpan: "<I>",
+	}
+	got, err := UnifiedDiffString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"--- a",
+		"+++ b",
+		"@@ -1,3 +1,3 @@",
+		" x",
+		"<d>-var n = " + colorReset + "<D>3" + colorReset + "<d>" +
+			colorReset,
+		"<i>+var n = " + colorReset + "<I>42" + colorReset + "<i>" +
+			colorReset,
+		" y",
+		"",
+	}, "\n")
+	assertEqual(t, got, want)
+}
+
+func TestJSONDiff(t *testing.T) {
+	d := MakeJSONDiff(intralineInput())
+	assertEqual(t, len(d.Hunks), 1)
+	h := d.Hunks[0]
+	assertEqual(t, []int{h.OldStart, h.OldLines, h.NewStart, h.NewLines},
+		[]int{1, 3, 1, 3})
+	assertEqual(t, len(h.Lines), 4)
+	assertEqual(t, *h.Lines[1], JSONLine{
+		Op:      "-",
+		Text:    "var n = 3",
+		OldLine: 2,
+		Spans:   []Span{{Start: 8, End: 9}},
+	})
+	assertEqual(t, *h.Lines[3], JSONLine{
+		Op:      " ",
+		Text:    "y",
+		OldLine: 3,
+		NewLine: 3,
+	})
+}
+
+func TestHTMLDiff(t *testing.T) {
+	in := &Input{
+		A:         NewBytesFile("a", []byte("<b>old</b>\n")),
+		B:         NewBytesFile("b", []byte("<b>new</b>\n")),
+		Intraline: true,
+	}
+	got, err := HTMLDiffString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<tr class="hunk"><td colspan="3">@@ -1 +1 @@</td></tr>`,
+		`<td class="text">-&lt;b&gt;<del>old</del>&lt;/b&gt;</td>`,
+		`<td class="num"></td><td class="num">1</td>` +
+			`<td class="text">+&lt;b&gt;<ins>new</ins>&lt;/b&gt;</td>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("html diff %q does not contain %q", got, want)
+		}
+	}
+}
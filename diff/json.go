@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// JSONDiff is the JSON form of a diff between two files.
+type JSONDiff struct {
+	OldName string      `json:"oldName"`
+	NewName string      `json:"newName"`
+	Binary  bool        `json:"binary,omitempty"`
+	Hunks   []*JSONHunk `json:"hunks"`
+}
+
+// JSONHunk is a hunk of a JSONDiff. The ranges are the same as in the
+// "@@" line of a unified diff.
+type JSONHunk struct {
+	OldStart int         `json:"oldStart"`
+	OldLines int         `json:"oldLines"`
+	NewStart int         `json:"newStart"`
+	NewLines int         `json:"newLines"`
+	Lines    []*JSONLine `json:"lines"`
+}
+
+// JSONLine is a line of a JSONHunk.
+type JSONLine struct {
+	// Op is " " for a context line, "-" for a deleted line, and "+" for
+	// an inserted line.
+	Op string `json:"op"`
+
+	// Text is the line without its line ending.
+	Text string `json:"text"`
+
+	// OldLine and NewLine are the 1-based line numbers in the two files,
+	// or 0 if the line is not in the file.
+	OldLine int `json:"oldLine,omitempty"`
+	NewLine int `json:"newLine,omitempty"`
+
+	// NoNewline is true for the last line of a file that does not end
+	// with a line ending. It is only set for files read from bytes.
+	NoNewline bool `json:"noNewline,omitempty"`
+
+	// Spans are the changed characters in the line, when Input.Intraline
+	// is set.
+	Spans []Span `json:"spans,omitempty"`
+}
+
+func hunkStart(start, n int) int {
+	if n == 0 {
+		return start
+	}
+	return start + 1
+}
+
+func jsonLine(f *File, op string, i int, spans []Span) *JSONLine {
+	ret := &JSONLine{
+		Op:    op,
+		Text:  trimEOL(f.Lines[i]),
+		Spans: spans,
+	}
+	if f.Eols != nil {
+		ret.NoNewline = f.Eols[i] == ""
+	}
+	return ret
+}
+
+// MakeJSONDiff makes the JSON form of the diff of the input, with the same
+// hunks as WriteUnifiedDiff.
+func MakeJSONDiff(in *Input) *JSONDiff {
+	ret := &JSONDiff{
+		OldName: in.A.Name,
+		NewName: in.B.Name,
+		Hunks:   []*JSONHunk{},
+	}
+	if isBinary, differs := binaryDiffers(in); isBinary {
+		ret.Binary = differs
+		return ret
+	}
+
+	codes := inputGroupedOpCodes(in)
+	spans := inputSpans(in, codes)
+	for _, g := range codes {
+		first, last := g[0], g[len(g)-1]
+		h := &JSONHunk{
+			OldLines: last.I2 - first.I1,
+			NewLines: last.J2 - first.J1,
+		}
+		h.OldStart = hunkStart(first.I1, h.OldLines)
+		h.NewStart = hunkStart(first.J1, h.NewLines)
+		for _, c := range g {
+			if c.Tag == 'e' {
+				for i := c.I1; i < c.I2; i++ {
+					line := jsonLine(in.A, " ", i, nil)
+					line.OldLine = i + 1
+					line.NewLine = c.J1 + i - c.I1 + 1
+					h.Lines = append(h.Lines, line)
+				}
+				continue
+			}
+			for i := c.I1; i < c.I2; i++ {
+				line := jsonLine(in.A, "-", i, spans.spansA(i))
+				line.OldLine = i + 1
+				h.Lines = append(h.Lines, line)
+			}
+			for j := c.J1; j < c.J2; j++ {
+				line := jsonLine(in.B, "+", j, spans.spansB(j))
+				line.NewLine = j + 1
+				h.Lines = append(h.Lines, line)
+			}
+		}
+		ret.Hunks = append(ret.Hunks, h)
+	}
+	return ret
+}
+
+// WriteJSONDiff writes the JSON form of the diff of the input as one line
+// of JSON.
+func WriteJSONDiff(w io.Writer, in *Input) error {
+	return json.NewEncoder(w).Encode(MakeJSONDiff(in))
+}
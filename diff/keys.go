@@ -0,0 +1,68 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// IgnoreSpaceChange is a line key that ignores changes in the amount of
+// white space. Runs of white space compare equal to a single space, and
+// white space at line end is ignored. It is like the -b option of GNU diff.
+func IgnoreSpaceChange(line string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.TrimRightFunc(line, unicode.IsSpace) {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// IgnoreAllSpace is a line key that ignores all white space. It is like the
+// -w option of GNU diff.
+func IgnoreAllSpace(line string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, line)
+}
+
+// IgnoreTrailingSpace is a line key that ignores white space at line end,
+// including the line ending. It is like the -Z option of GNU diff.
+func IgnoreTrailingSpace(line string) string {
+	return strings.TrimRightFunc(line, unicode.IsSpace)
+}
+
+// IgnoreCase is a line key that ignores case differences.
+func IgnoreCase(line string) string {
+	return strings.ToLower(line)
+}
+
+// ChainKeys returns a line key that applies keys in order. Nil keys are
+// skipped. It returns nil if there are no keys.
+func ChainKeys(keys ...func(string) string) func(string) string {
+	var fs []func(string) string
+	for _, k := range keys {
+		if k != nil {
+			fs = append(fs, k)
+		}
+	}
+	if len(fs) == 0 {
+		return nil
+	}
+	return func(line string) string {
+		for _, f := range fs {
+			line = f(line)
+		}
+		return line
+	}
+}
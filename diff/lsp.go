@@ -0,0 +1,90 @@
+package diff
+
+import (
+	"fmt"
+
+	"shanhu.io/third/diffmp"
+)
+
+// LSPPosition is a position in a text in the Language Server Protocol:
+// a 0-based line, and a 0-based character offset in UTF-16 code units.
+type LSPPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// LSPRange is a range of a text in the Language Server Protocol.
+type LSPRange struct {
+	Start LSPPosition `json:"start"`
+	End   LSPPosition `json:"end"`
+}
+
+// LSPTextEdit is a TextEdit in the Language Server Protocol.
+type LSPTextEdit struct {
+	Range   LSPRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+func lspPosition(x *diffmp.TextIndex, off int) (LSPPosition, error) {
+	line, col, err := x.LineCol(off, diffmp.UnitUTF16)
+	if err != nil {
+		return LSPPosition{}, err
+	}
+	return LSPPosition{Line: line, Character: col}, nil
+}
+
+// LSPEdits converts byte-offset edits of s, such as from ByteEdits, to
+// LSP text edits.
+func LSPEdits(s string, edits []*TextEdit) ([]*LSPTextEdit, error) {
+	if err := checkTextEdits(edits, len(s)); err != nil {
+		return nil, err
+	}
+	x := diffmp.NewTextIndex(s)
+	ret := make([]*LSPTextEdit, 0, len(edits))
+	for i, e := range edits {
+		start, err := lspPosition(x, e.Start)
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %s", i, err)
+		}
+		end, err := lspPosition(x, e.End)
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %s", i, err)
+		}
+		ret = append(ret, &LSPTextEdit{
+			Range:   LSPRange{Start: start, End: end},
+			NewText: e.NewText,
+		})
+	}
+	return ret, nil
+}
+
+// LSPTextEdits converts LSP text edits of s to byte-offset edits.
+func LSPTextEdits(s string, edits []*LSPTextEdit) ([]*TextEdit, error) {
+	x := diffmp.NewTextIndex(s)
+	offset := func(p LSPPosition) (int, error) {
+		return x.Offset(p.Line, p.Character, diffmp.UnitUTF16)
+	}
+	ret := make([]*TextEdit, 0, len(edits))
+	for i, e := range edits {
+		start, err := offset(e.Range.Start)
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %s", i, err)
+		}
+		end, err := offset(e.Range.End)
+		if err != nil {
+			return nil, fmt.Errorf("edit %d: %s", i, err)
+		}
+		ret = append(ret, &TextEdit{start, end, e.NewText})
+	}
+	return ret, nil
+}
+
+// ApplyLSPEdits applies LSP text edits to s. Like ApplyTextEdits, the
+// edits must be in order and must not overlap.
+func ApplyLSPEdits(s string, edits []*LSPTextEdit) (string, error) {
+	bs, err := LSPTextEdits(s, edits)
+	if err != nil {
+		return "", err
+	}
+	return ApplyTextEdits(s, bs)
+}
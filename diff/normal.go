@@ -0,0 +1,81 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// formatRangeNormal converts a range to the format of normal diffs.
+func formatRangeNormal(start, stop int) string {
+	if stop-start <= 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, stop)
+}
+
+// WriteNormalDiff compares two sequences of lines; generate the delta in the
+// default output format of diff(1), which has no context lines.
+//
+// Each change starts with a command line like "2,3c2,4", where the letter
+// is 'a' for add, 'd' for delete or 'c' for change. Deleted lines follow
+// with a "< " prefix, and added lines with a "> " prefix. Changes are
+// separated by "---" lines.
+func WriteNormalDiff(writer io.Writer, in *Input) error {
+	var diffErr error
+	ws := func(s string) {
+		_, err := fmt.Fprint(writer, s)
+		if diffErr == nil && err != nil {
+			diffErr = err
+		}
+	}
+
+	if in.Eol == "" {
+		in.Eol = "\n"
+	}
+	if isBinary, differs := binaryDiffers(in); isBinary {
+		if differs {
+			return writeBinaryDiffers(writer, in)
+		}
+		return nil
+	}
+
+	colors := in.Colors
+	for _, c := range inputOpCodes(in) {
+		var cmd string
+		switch c.Tag {
+		case 'e':
+			continue
+		case 'i':
+			cmd = fmt.Sprintf("%da%s", c.I1, formatRangeNormal(c.J1, c.J2))
+		case 'd':
+			cmd = fmt.Sprintf("%sd%d", formatRangeNormal(c.I1, c.I2), c.J1)
+		case 'r':
+			cmd = fmt.Sprintf(
+				"%sc%s",
+				formatRangeNormal(c.I1, c.I2),
+				formatRangeNormal(c.J1, c.J2),
+			)
+		}
+		ws(paint(colors.hunk(), cmd+in.Eol))
+
+		for _, line := range in.A.outLines(c.I1, c.I2) {
+			ws(paint(colors.delete(), "< "+line))
+		}
+		if c.Tag == 'r' {
+			ws("---" + in.Eol)
+		}
+		for _, line := range in.B.outLines(c.J1, c.J2) {
+			ws(paint(colors.insert(), "> "+line))
+		}
+	}
+	return diffErr
+}
+
+// NormalDiffString works like WriteNormalDiff but returns the diff as a
+// string.
+func NormalDiffString(in *Input) (string, error) {
+	w := new(bytes.Buffer)
+	err := WriteNormalDiff(w, in)
+	return w.String(), err
+}
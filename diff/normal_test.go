@@ -0,0 +1,109 @@
+package diff
+
+import (
+	"testing"
+)
+
+func formatTestInput() *Input {
+	return &Input{
+		A: NewStringFile("a", "one\ntwo\nthree\nfour"),
+		B: NewStringFile("b", "one\n2\nthree\nfour\nfive"),
+	}
+}
+
+func TestNormalDiff(t *testing.T) {
+	got, err := NormalDiffString(formatTestInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2c2\n< two\n---\n> 2\n4a5\n> five\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRCSDiff(t *testing.T) {
+	got, err := RCSDiffString(formatTestInput())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "d2 1\na2 1\n2\na4 1\nfive\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSideBySideDiff(t *testing.T) {
+	in := formatTestInput()
+	in.Width = 23
+	got, err := SideBySideDiffString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "one          one\n" +
+		"two        | 2\n" +
+		"three        three\n" +
+		"four         four\n" +
+		"           > five\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestIgnoreKeys(t *testing.T) {
+	for _, test := range []struct {
+		key    func(string) string
+		a, b   string
+		differ bool
+	}{
+		{IgnoreSpaceChange, "a  b \n", "a b\n", false},
+		{IgnoreSpaceChange, " ab\n", "ab\n", true},
+		{IgnoreAllSpace, "a b\n", "ab\n", false},
+		{IgnoreTrailingSpace, "a \n", "a\n", false},
+		{IgnoreTrailingSpace, " a\n", "a\n", true},
+		{IgnoreCase, "ABC\n", "abc\n", false},
+		{ChainKeys(IgnoreCase, IgnoreAllSpace), "A B\n", "ab\n", false},
+		{nil, "a\n", "a \n", true},
+	} {
+		in := &Input{
+			A:   NewStringFile("a", test.a),
+			B:   NewStringFile("b", test.b),
+			Key: test.key,
+		}
+		if got := Differs(in); got != test.differ {
+			t.Errorf("%q vs %q differs: got %v", test.a, test.b, got)
+		}
+	}
+}
+
+func TestIgnoreBlankLines(t *testing.T) {
+	in := &Input{
+		A:                NewStringFile("a", "one\ntwo"),
+		B:                NewStringFile("b", "one\n\n\ntwo"),
+		IgnoreBlankLines: true,
+	}
+	if Differs(in) {
+		t.Errorf("blank line changes should be ignored")
+	}
+	got, err := UnifiedDiffString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+}
+
+func TestColors(t *testing.T) {
+	in := formatTestInput()
+	in.Colors = DefaultColors
+	got, err := NormalDiffString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1b[36m2c2\x1b[m\n\x1b[31m< two\x1b[m\n---\n" +
+		"\x1b[32m> 2\x1b[m\n\x1b[36m4a5\x1b[m\n\x1b[32m> five\x1b[m\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
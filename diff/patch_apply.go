@@ -0,0 +1,133 @@
+package diff
+
+import (
+	"strings"
+)
+
+// ApplyOptions are options for applying hunks.
+type ApplyOptions struct {
+	// Fuzz is the maximum number of leading and trailing context lines
+	// that might be ignored when a hunk does not match exactly. patch(1)
+	// uses 2 by default.
+	Fuzz int
+}
+
+// HunkResult is the result of applying one hunk.
+type HunkResult struct {
+	Applied bool
+
+	// Line is the 1-based line where the hunk is applied in the result,
+	// or where it is expected in the original file if not applied.
+	Line int
+
+	// Offset is the number of lines between where the hunk matched and
+	// where its header says, counted in the original file.
+	Offset int
+
+	// Fuzz is the number of leading or trailing context lines that were
+	// ignored to make the hunk match.
+	Fuzz int
+}
+
+// SplitFileLines splits content into lines for applying hunks. Each line
+// keeps its "\n"; the last line has none if the content does not end
+// with one.
+func SplitFileLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func linesMatchAt(lines, pattern []string, pos int) bool {
+	if pos < 0 || pos+len(pattern) > len(lines) {
+		return false
+	}
+	for i, p := range pattern {
+		if lines[pos+i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+// findLines finds pattern in lines[lo:], starting at pos and moving away
+// from it in both directions. It returns -1 if not found.
+func findLines(lines, pattern []string, pos, lo int) int {
+	hi := len(lines) - len(pattern)
+	if pos < lo {
+		pos = lo
+	}
+	if pos > hi {
+		pos = hi
+	}
+	for d := 0; pos-d >= lo || pos+d <= hi; d++ {
+		if pos+d <= hi && linesMatchAt(lines, pattern, pos+d) {
+			return pos + d
+		}
+		if d > 0 && pos-d >= lo && linesMatchAt(lines, pattern, pos-d) {
+			return pos - d
+		}
+	}
+	return -1
+}
+
+// ApplyHunks applies hunks in order on lines, which are split with
+// SplitFileLines. A hunk is searched near where its header says, shifted
+// by the offset of the previous hunk, and after the end of the previous
+// hunk. If it does not match exactly, up to opts.Fuzz lines of leading and
+// trailing context are ignored.
+//
+// It returns the patched lines with the hunks that applied, and the
+// result of each hunk.
+func ApplyHunks(lines []string, hunks []*Hunk, opts *ApplyOptions) (
+	[]string, []*HunkResult,
+) {
+	maxFuzz := 0
+	if opts != nil {
+		maxFuzz = opts.Fuzz
+	}
+
+	var out []string
+	results := make([]*HunkResult, len(hunks))
+	last := 0   // end of the previous hunk in lines
+	offset := 0 // offset of the previous applied hunk
+	for i, h := range hunks {
+		old, cur := h.side(false), h.side(true)
+		lead, trail := h.contextLen()
+		expect := h.oldIndex()
+
+		res := &HunkResult{Line: expect + offset + 1}
+		results[i] = res
+		for fuzz := 0; fuzz <= maxFuzz; fuzz++ {
+			// Ignore context lines from both ends, but never more than
+			// the hunk has.
+			cutLead, cutTrail := min(fuzz, lead), min(fuzz, trail)
+			if fuzz > 0 && cutLead == 0 && cutTrail == 0 {
+				break
+			}
+			if fuzz > max(lead, trail) {
+				break
+			}
+			pattern := old[cutLead : len(old)-cutTrail]
+			pos := findLines(lines, pattern, expect+offset+cutLead, last)
+			if pos < 0 {
+				continue
+			}
+
+			start := pos - cutLead
+			out = append(out, lines[last:pos]...)
+			res.Applied = true
+			res.Line = len(out) + 1 - cutLead
+			res.Offset = start - expect
+			res.Fuzz = fuzz
+			out = append(out, cur[cutLead:len(cur)-cutTrail]...)
+			last = pos + len(pattern)
+			offset = res.Offset
+			break
+		}
+	}
+	out = append(out, lines[last:]...)
+	return out, results
+}
@@ -0,0 +1,152 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func applyTestPatch(t *testing.T, a, b string) {
+	in := &Input{
+		A:       NewBytesFile("a/f", []byte(a)),
+		B:       NewBytesFile("b/f", []byte(b)),
+		Context: 3,
+	}
+	text, err := UnifiedDiffString(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps, err := ParsePatch(text)
+	if err != nil {
+		t.Fatalf("parse %q: %s", text, err)
+	}
+	if len(ps) != 1 {
+		t.Fatalf("got %d file patches, want 1", len(ps))
+	}
+
+	got, results := ApplyHunks(SplitFileLines(a), ps[0].Hunks, nil)
+	for i, r := range results {
+		if !r.Applied || r.Offset != 0 || r.Fuzz != 0 {
+			t.Errorf("hunk %d of %q: %+v", i, text, r)
+		}
+	}
+	if s := strings.Join(got, ""); s != b {
+		t.Errorf("apply %q: got %q, want %q", text, s, b)
+	}
+
+	back, _ := ApplyHunks(got, ps[0].Reverse().Hunks, nil)
+	if s := strings.Join(back, ""); s != a {
+		t.Errorf("reverse apply %q: got %q, want %q", text, s, a)
+	}
+}
+
+func TestApplyHunksRoundTrip(t *testing.T) {
+	lines := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	for _, test := range []struct{ a, b string }{
+		{lines, strings.Replace(lines, "2\n", "two\n", 1)},
+		{lines, strings.Replace(lines, "11\n", "", 1)},
+		{lines, "0\n" + lines + "13\n"},
+		{lines, strings.Replace(lines, "5\n6\n", "", 1) + "x"},
+		{"a\nb", "a\nc"},
+		{"", "new\n"},
+		{"old\n", ""},
+		{"a\r\nb\r\n", "a\r\nc\r\n"},
+	} {
+		applyTestPatch(t, test.a, test.b)
+	}
+}
+
+func TestApplyHunksOffsetAndFuzz(t *testing.T) {
+	patch := "--- a/f\n+++ b/f\n" +
+		"@@ -2,5 +2,5 @@\n" +
+		" b\n c\n d\n-e\n+E\n f\n"
+	ps, err := ParsePatch(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hunks := ps[0].Hunks
+
+	moved := SplitFileLines("x\ny\na\nb\nc\nd\ne\nf\ng\n")
+	got, results := ApplyHunks(moved, hunks, nil)
+	if r := results[0]; !r.Applied || r.Offset != 2 || r.Line != 4 {
+		t.Errorf("offset hunk: %+v", r)
+	}
+	want := "x\ny\na\nb\nc\nd\nE\nf\ng\n"
+	if s := strings.Join(got, ""); s != want {
+		t.Errorf("got %q, want %q", s, want)
+	}
+
+	fuzzy := SplitFileLines("a\nB\nc\nd\ne\nf\ng\n")
+	_, results = ApplyHunks(fuzzy, hunks, nil)
+	if results[0].Applied {
+		t.Errorf("hunk should not apply without fuzz")
+	}
+	got, results = ApplyHunks(fuzzy, hunks, &ApplyOptions{Fuzz: 2})
+	if r := results[0]; !r.Applied || r.Fuzz != 1 {
+		t.Errorf("fuzzy hunk: %+v", r)
+	}
+	want = "a\nB\nc\nd\nE\nf\ng\n"
+	if s := strings.Join(got, ""); s != want {
+		t.Errorf("got %q, want %q", s, want)
+	}
+}
+
+func TestParseGitPatch(t *testing.T) {
+	patch := strings.Join([]string{
+		"From: someone",
+		"Subject: a commit message",
+		"",
+		"diff --git a/old.txt b/new.txt",
+		"similarity index 90%",
+		"rename from old.txt",
+		"rename to new.txt",
+		"index 1111111..2222222 100644",
+		"--- a/old.txt",
+		"+++ b/new.txt",
+		"@@ -1 +1 @@",
+		"-x",
+		"\\ No newline at end of file",
+		"+y",
+		"diff --git a/gone b/gone",
+		"deleted file mode 100644",
+		"index 3333333..0000000",
+		"--- a/gone",
+		"+++ /dev/null",
+		"@@ -1 +0,0 @@",
+		"-bye",
+		"diff --git a/img.png b/img.png",
+		"new file mode 100644",
+		"index 0000000..4444444",
+		"Binary files /dev/null and b/img.png differ",
+		"",
+	}, "\n")
+	ps, err := ParsePatch(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 3 {
+		t.Fatalf("got %d file patches, want 3", len(ps))
+	}
+
+	p := ps[0]
+	if !p.IsRename || p.OldName != "a/old.txt" || p.NewName != "b/new.txt" {
+		t.Errorf("rename patch: %+v", p)
+	}
+	if p.OldHash != "1111111" || p.NewMode != "100644" {
+		t.Errorf("rename patch index: %+v", p)
+	}
+	assertEqual(t, p.Hunks[0].Lines, []string{"-x", "+y\n"})
+
+	if p := ps[1]; !p.IsDelete() || p.OldName != "a/gone" {
+		t.Errorf("delete patch: %+v", p)
+	}
+	if p := ps[2]; !p.IsNew() || !p.BinaryNoData || p.Name() != "b/img.png" {
+		t.Errorf("binary patch: %+v", p)
+	}
+}
+
+func TestStripPath(t *testing.T) {
+	assertEqual(t, StripPath("a/b/c", 0), "a/b/c")
+	assertEqual(t, StripPath("a/b/c", 1), "b/c")
+	assertEqual(t, StripPath("a//b/c", 2), "c")
+	assertEqual(t, StripPath("a/b/c", 3), "")
+}
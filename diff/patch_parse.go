@@ -0,0 +1,191 @@
+package diff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parsePatchName parses a file name in a "---", "+++" or git header line.
+// The name might be C-style quoted, and might be followed by a tab and a
+// time stamp. DevNull is returned as an empty name.
+func parsePatchName(s string) (name, rest string) {
+	s = strings.TrimRight(s, "\r\n")
+	if strings.HasPrefix(s, `"`) {
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				if name, err := strconv.Unquote(s[:i+1]); err == nil {
+					return name, s[i+1:]
+				}
+				break
+			}
+		}
+	}
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	if s == DevNull {
+		return "", ""
+	}
+	return s, ""
+}
+
+// parseGitNames parses the names in a "diff --git a/x b/y" line.
+func parseGitNames(s string) (a, b string) {
+	s = strings.TrimRight(s, "\r\n")
+	if strings.HasPrefix(s, `"`) {
+		a, rest := parsePatchName(s)
+		b, _ := parsePatchName(strings.TrimPrefix(rest, " "))
+		return a, b
+	}
+	if k := (len(s) - 1) / 2; len(s)%2 == 1 && s[k] == ' ' {
+		// Without a rename, both names are the same after their prefixes.
+		a, b := s[:k], s[k+1:]
+		if StripPath(a, 1) == StripPath(b, 1) {
+			return a, b
+		}
+	}
+	if i := strings.Index(s, " b/"); i >= 0 {
+		a, rest := s[:i], s[i+1:]
+		b, _ := parsePatchName(rest)
+		return a, b
+	}
+	if i := strings.IndexByte(s, ' '); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, s
+}
+
+// parseGitHeader parses one git extended header line. It returns false if
+// the line is not an extended header.
+func parseGitHeader(p *FilePatch, line string) bool {
+	line = strings.TrimRight(line, "\r\n")
+	for _, prefix := range []string{"rename ", "copy "} {
+		if strings.HasPrefix(line, prefix+"from ") ||
+			strings.HasPrefix(line, prefix+"to ") {
+			// The names are in the "diff --git" line already.
+			p.IsRename = prefix == "rename "
+			p.IsCopy = prefix == "copy "
+			return true
+		}
+	}
+	i := strings.LastIndexByte(line, ' ')
+	if i < 0 {
+		return false
+	}
+	key, v := line[:i], line[i+1:]
+	switch key {
+	case "old mode":
+		p.OldMode = v
+	case "new mode":
+		p.NewMode = v
+	case "deleted file mode":
+		p.OldMode = v
+		p.NewName = ""
+	case "new file mode":
+		p.NewMode = v
+		p.OldName = ""
+	case "similarity index", "dissimilarity index":
+	default:
+		if !strings.HasPrefix(line, "index ") {
+			return false
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "index "))
+		hashes := strings.SplitN(fields[0], "..", 2)
+		if len(hashes) == 2 {
+			p.OldHash, p.NewHash = hashes[0], hashes[1]
+		}
+		if len(fields) > 1 && p.OldMode == "" && p.NewMode == "" {
+			p.OldMode, p.NewMode = fields[1], fields[1]
+		}
+	}
+	return true
+}
+
+// parseFilePatch parses the patch of one file that starts at lines[0] with
+// a "diff --git" line or a "---" line. It returns the patch and the number
+// of lines consumed.
+func parseFilePatch(lines []string) (*FilePatch, int, error) {
+	p := new(FilePatch)
+	n := 0
+	isGit := strings.HasPrefix(lines[0], "diff --git ")
+	if isGit {
+		p.OldName, p.NewName = parseGitNames(
+			strings.TrimPrefix(lines[0], "diff --git "),
+		)
+		n++
+		for n < len(lines) && parseGitHeader(p, lines[n]) {
+			n++
+		}
+	}
+
+	if n+1 < len(lines) && strings.HasPrefix(lines[n], "--- ") &&
+		strings.HasPrefix(lines[n+1], "+++ ") {
+		p.OldName, _ = parsePatchName(strings.TrimPrefix(lines[n], "--- "))
+		p.NewName, _ = parsePatchName(
+			strings.TrimPrefix(lines[n+1], "+++ "),
+		)
+		n += 2
+	} else if !isGit {
+		return nil, 0, fmt.Errorf("missing file names: %q", lines[n])
+	}
+
+	if n < len(lines) {
+		switch {
+		case strings.HasPrefix(lines[n], binaryPatchHeader):
+			bp, consumed, err := parseBinaryPatch(lines[n:])
+			if err != nil {
+				return nil, 0, err
+			}
+			p.Binary = bp
+			return p, n + consumed, nil
+		case strings.HasPrefix(lines[n], "Binary files "):
+			p.BinaryNoData = true
+			return p, n + 1, nil
+		}
+	}
+
+	for n < len(lines) && strings.HasPrefix(lines[n], "@@ ") {
+		h, consumed, err := parseHunk(lines[n:])
+		if err != nil {
+			return nil, 0, err
+		}
+		p.Hunks = append(p.Hunks, h)
+		n += consumed
+	}
+	return p, n, nil
+}
+
+// ParsePatch parses a patch in the unified diff format. The patch might
+// have changes of multiple files, in git's format or in the format of
+// "diff -ur". Lines that are not part of a file patch, such as commit
+// messages, are skipped.
+func ParsePatch(s string) ([]*FilePatch, error) {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var ret []*FilePatch
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		isGit := strings.HasPrefix(line, "diff --git ")
+		isUnified := strings.HasPrefix(line, "--- ") &&
+			i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+		if !isGit && !isUnified {
+			i++
+			continue
+		}
+		p, n, err := parseFilePatch(lines[i:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		ret = append(ret, p)
+		i += n
+	}
+	return ret, nil
+}
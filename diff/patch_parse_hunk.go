@@ -0,0 +1,78 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var hunkHeader = regexp.MustCompile(
+	`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@(.*)$`,
+)
+
+func parseHunkCount(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// parseHunk parses a hunk that starts at lines[0] with its header. It
+// returns the hunk and the number of lines consumed.
+func parseHunk(lines []string) (*Hunk, int, error) {
+	head := strings.TrimRight(lines[0], "\r\n")
+	m := hunkHeader.FindStringSubmatch(head)
+	if m == nil {
+		return nil, 0, fmt.Errorf("invalid hunk header: %q", head)
+	}
+	h := new(Hunk)
+	h.OldStart, _ = strconv.Atoi(m[1])
+	h.OldLines = parseHunkCount(m[2])
+	h.NewStart, _ = strconv.Atoi(m[3])
+	h.NewLines = parseHunkCount(m[4])
+	h.Section = m[5]
+
+	nold, nnew := 0, 0
+	n := 1
+	for ; n < len(lines); n++ {
+		line := lines[n]
+		if strings.HasPrefix(line, `\`) {
+			// "\ No newline at end of file" marks the previous line.
+			if len(h.Lines) > 0 {
+				last := &h.Lines[len(h.Lines)-1]
+				*last = strings.TrimSuffix(*last, "\n")
+			}
+			continue
+		}
+		if nold >= h.OldLines && nnew >= h.NewLines {
+			break
+		}
+		if line == "\n" || line == "\r\n" {
+			// Some tools strip the space of an empty context line.
+			line = " " + line
+		}
+		switch line[0] {
+		case ' ':
+			nold++
+			nnew++
+		case '-':
+			nold++
+		case '+':
+			nnew++
+		default:
+			return nil, 0, fmt.Errorf(
+				"hunk %q is cut short at line %q", head, line,
+			)
+		}
+		if nold > h.OldLines || nnew > h.NewLines {
+			return nil, 0, fmt.Errorf("hunk %q has too many lines", head)
+		}
+		h.Lines = append(h.Lines, line)
+	}
+	if nold < h.OldLines || nnew < h.NewLines {
+		return nil, 0, fmt.Errorf("hunk %q is cut short", head)
+	}
+	return h, n, nil
+}
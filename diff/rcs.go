@@ -0,0 +1,53 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// WriteRCSDiff compares two sequences of lines; generate the delta in the
+// RCS format, like the -n option of diff(1).
+//
+// Each change is a command line, "dL N" that deletes N lines starting
+// at line L, or "aL N" that adds the N lines that follow after line L.
+// Line numbers always refer to the first file.
+func WriteRCSDiff(writer io.Writer, in *Input) error {
+	var diffErr error
+	ws := func(s string) {
+		_, err := fmt.Fprint(writer, s)
+		if diffErr == nil && err != nil {
+			diffErr = err
+		}
+	}
+
+	if in.Eol == "" {
+		in.Eol = "\n"
+	}
+	if isBinary, differs := binaryDiffers(in); isBinary {
+		if differs {
+			return writeBinaryDiffers(writer, in)
+		}
+		return nil
+	}
+
+	for _, c := range inputOpCodes(in) {
+		if c.Tag == 'd' || c.Tag == 'r' {
+			ws(fmt.Sprintf("d%d %d%s", c.I1+1, c.I2-c.I1, in.Eol))
+		}
+		if c.Tag == 'i' || c.Tag == 'r' {
+			ws(fmt.Sprintf("a%d %d%s", c.I2, c.J2-c.J1, in.Eol))
+			for _, line := range in.B.outLines(c.J1, c.J2) {
+				ws(line)
+			}
+		}
+	}
+	return diffErr
+}
+
+// RCSDiffString works like WriteRCSDiff but returns the diff as a string.
+func RCSDiffString(in *Input) (string, error) {
+	w := new(bytes.Buffer)
+	err := WriteRCSDiff(w, in)
+	return w.String(), err
+}
@@ -0,0 +1,115 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const defaultSideBySideWidth = 130
+
+// fitColumn expands tabs in a line, removes its line ending and cuts it to
+// fit in a column.
+func fitColumn(line string, width int) string {
+	line = trimEOL(line)
+	var b strings.Builder
+	n := 0
+	for _, r := range line {
+		if r == '\t' {
+			pad := 8 - n%8
+			if n+pad > width {
+				break
+			}
+			b.WriteString(strings.Repeat(" ", pad))
+			n += pad
+			continue
+		}
+		if n+1 > width {
+			break
+		}
+		b.WriteRune(r)
+		n++
+	}
+	return b.String()
+}
+
+// sideBySide formats one output line with the left and right columns and
+// the gutter mark between them.
+func sideBySide(left, right string, mark byte, col int) string {
+	pad := col - utf8.RuneCountInString(left)
+	s := left + strings.Repeat(" ", pad) + " " + string(mark) + " " + right
+	return strings.TrimRight(s, " ")
+}
+
+// WriteSideBySideDiff compares two sequences of lines; generate the delta in
+// two columns, like the -y option of diff(1).
+//
+// The gutter between the columns is ' ' for common lines, '|' for changed
+// lines, '<' for deleted lines and '>' for inserted lines. The total width
+// is set by in.Width.
+func WriteSideBySideDiff(writer io.Writer, in *Input) error {
+	var diffErr error
+	ws := func(s string) {
+		_, err := fmt.Fprint(writer, s)
+		if diffErr == nil && err != nil {
+			diffErr = err
+		}
+	}
+
+	if in.Eol == "" {
+		in.Eol = "\n"
+	}
+	if isBinary, differs := binaryDiffers(in); isBinary {
+		if differs {
+			return writeBinaryDiffers(writer, in)
+		}
+		return nil
+	}
+
+	width := in.Width
+	if width <= 0 {
+		width = defaultSideBySideWidth
+	}
+	col := max((width-3)/2, 1)
+	colors := in.Colors
+
+	for _, c := range inputMatcher(in).OpCodes() {
+		n := max(c.I2-c.I1, c.J2-c.J1)
+		for k := 0; k < n; k++ {
+			var left, right string
+			hasLeft := c.I1+k < c.I2
+			hasRight := c.J1+k < c.J2
+			if hasLeft {
+				left = fitColumn(in.A.Lines[c.I1+k], col)
+			}
+			if hasRight {
+				right = fitColumn(in.B.Lines[c.J1+k], col)
+			}
+
+			mark := byte(' ')
+			color := ""
+			switch {
+			case c.Tag == 'e':
+			case ignorable(in, c):
+			case hasLeft && hasRight:
+				mark, color = '|', colors.hunk()
+			case hasLeft:
+				mark, color = '<', colors.delete()
+			default:
+				mark, color = '>', colors.insert()
+			}
+			ws(paint(color, sideBySide(left, right, mark, col)) + in.Eol)
+		}
+	}
+	return diffErr
+}
+
+// SideBySideDiffString works like WriteSideBySideDiff but returns the diff
+// as a string.
+func SideBySideDiffString(in *Input) (string, error) {
+	w := new(bytes.Buffer)
+	err := WriteSideBySideDiff(w, in)
+	return w.String(), err
+}
@@ -0,0 +1,113 @@
+package diff
+
+import (
+	"bytes"
+)
+
+// ContentType classifies the content of a file for diffing.
+type ContentType int
+
+// Content types.
+const (
+	Text ContentType = iota
+	Binary
+)
+
+func (t ContentType) String() string {
+	switch t {
+	case Text:
+		return "text"
+	case Binary:
+		return "binary"
+	}
+	return "unknown"
+}
+
+// DefaultPeekSize is the number of leading bytes that are examined when
+// sniffing content. It is the same as what git uses.
+const DefaultPeekSize = 8000
+
+// Sniffer classifies file content as text or binary.
+type Sniffer struct {
+	// MaxTextSize is the maximum size of a text file. Larger content is
+	// always treated as binary. 0 means no limit.
+	MaxTextSize int
+
+	// PeekSize is the number of leading bytes that are examined.
+	// Defaults to DefaultPeekSize.
+	PeekSize int
+}
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// hasTextBOM checks if data starts with a UTF-8 or UTF-16 byte order mark.
+func hasTextBOM(data []byte) bool {
+	return bytes.HasPrefix(data, bomUTF8) ||
+		bytes.HasPrefix(data, bomUTF16LE) ||
+		bytes.HasPrefix(data, bomUTF16BE)
+}
+
+// isTextControl checks if a control byte commonly appears in text files.
+func isTextControl(b byte) bool {
+	switch b {
+	case '\t', '\n', '\r', '\f', '\b', '\v', 0x1b: // 0x1b is ESC
+		return true
+	}
+	return false
+}
+
+// Sniff classifies data as text or binary.
+//
+// Content larger than MaxTextSize is binary. Content that starts with a
+// UTF-8 or UTF-16 byte order mark is text. Otherwise, content that has a NUL
+// byte in the peeked prefix is binary, and so is content where more than
+// one in ten peeked bytes is a control byte that is not commonly seen in
+// text. Invalid UTF-8 alone does not make content binary, so legacy 8-bit
+// encodings such as Latin-1 are still text.
+func (s *Sniffer) Sniff(data []byte) ContentType {
+	if s.MaxTextSize > 0 && len(data) > s.MaxTextSize {
+		return Binary
+	}
+	if hasTextBOM(data) {
+		return Text
+	}
+
+	n := s.PeekSize
+	if n <= 0 {
+		n = DefaultPeekSize
+	}
+	peek := data
+	if len(peek) > n {
+		peek = peek[:n]
+	}
+
+	if bytes.IndexByte(peek, 0) >= 0 {
+		return Binary
+	}
+
+	odd := 0
+	for _, b := range peek {
+		if (b < 0x20 && !isTextControl(b)) || b == 0x7f {
+			odd++
+		}
+	}
+	if odd*10 > len(peek) {
+		return Binary
+	}
+	return Text
+}
+
+// Sniff classifies data as text or binary using the default sniffer.
+func Sniff(data []byte) ContentType {
+	s := &Sniffer{}
+	return s.Sniff(data)
+}
+
+// IsBinary checks if data is binary using the default sniffer.
+func IsBinary(data []byte) bool {
+	return Sniff(data) == Binary
+}
@@ -0,0 +1,44 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		want ContentType
+	}{
+		{"", Text},
+		{"hello\nworld\n", Text},
+		{"tab\tand\r\ncrlf\n", Text},
+		{"caf\xe9 latin-1\n", Text},
+		{"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", Binary},
+		{"abc\x00def", Binary},
+		{"\xff\xfeh\x00i\x00", Text},
+		{"\xef\xbb\xbfhi\n", Text},
+		{"\x01\x02\x03\x04abcdef", Binary},
+	} {
+		got := Sniff([]byte(test.data))
+		if got != test.want {
+			t.Errorf("Sniff(%q) = %s, want %s", test.data, got, test.want)
+		}
+	}
+}
+
+func TestSnifferLimits(t *testing.T) {
+	s := &Sniffer{MaxTextSize: 10}
+	if got := s.Sniff([]byte("0123456789a")); got != Binary {
+		t.Errorf("oversized content is %s, want binary", got)
+	}
+
+	late := strings.Repeat("a", DefaultPeekSize) + "\x00"
+	if got := Sniff([]byte(late)); got != Text {
+		t.Errorf("NUL after peek size is %s, want text", got)
+	}
+	s = &Sniffer{PeekSize: len(late)}
+	if got := s.Sniff([]byte(late)); got != Binary {
+		t.Errorf("NUL in peek size is %s, want binary", got)
+	}
+}
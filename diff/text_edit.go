@@ -0,0 +1,117 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// TextEdit replaces the bytes [Start, End) of a text with NewText, like
+// the TextEdits of a go/analysis SuggestedFix.
+type TextEdit struct {
+	Start, End int
+	NewText    string
+}
+
+// trimEdit trims the characters that the old and new text of an edit at
+// off start or end with. Bytes that are not valid UTF-8 are compared one
+// by one.
+func trimEdit(off int, old, new string) (int, string, string) {
+	for old != "" && new != "" {
+		_, n1 := utf8.DecodeRuneInString(old)
+		_, n2 := utf8.DecodeRuneInString(new)
+		if old[:n1] != new[:n2] {
+			break
+		}
+		off += n1
+		old, new = old[n1:], new[n2:]
+	}
+	for old != "" && new != "" {
+		_, n1 := utf8.DecodeLastRuneInString(old)
+		_, n2 := utf8.DecodeLastRuneInString(new)
+		if old[len(old)-n1:] != new[len(new)-n2:] {
+			break
+		}
+		old, new = old[:len(old)-n1], new[:len(new)-n2]
+	}
+	return off, old, new
+}
+
+// ByteEdits converts edits, such as from OpCodeEdits or DiffEdits, to a
+// minimal list of byte-offset edits of the old text. Each run of changes
+// between equal text becomes one edit, with the text that its old and
+// new text start or end with trimmed off. The edits are in order and do
+// not overlap.
+func ByteEdits(edits []Edit) []*TextEdit {
+	var ret []*TextEdit
+	pos := 0
+	start := -1
+	var old, new bytes.Buffer
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		off, o, n := trimEdit(start, old.String(), new.String())
+		if o != "" || n != "" {
+			ret = append(ret, &TextEdit{off, off + len(o), n})
+		}
+		start = -1
+		old.Reset()
+		new.Reset()
+	}
+	for _, e := range edits {
+		if e.Tag() == 'e' {
+			flush()
+			pos += len(e.Old())
+			continue
+		}
+		if start < 0 {
+			start = pos
+		}
+		old.WriteString(e.Old())
+		new.WriteString(e.New())
+		pos += len(e.Old())
+	}
+	flush()
+	return ret
+}
+
+// checkTextEdits checks that edits are in order, do not overlap and are
+// in a text of n bytes.
+func checkTextEdits(edits []*TextEdit, n int) error {
+	end := 0
+	for i, e := range edits {
+		if e.Start > e.End {
+			return fmt.Errorf("edit %d: invalid range [%d, %d)",
+				i, e.Start, e.End)
+		}
+		if e.Start < end {
+			return fmt.Errorf("edit %d: starts at %d, before %d",
+				i, e.Start, end)
+		}
+		if e.End > n {
+			return fmt.Errorf("edit %d: ends at %d, past the text end %d",
+				i, e.End, n)
+		}
+		end = e.End
+	}
+	return nil
+}
+
+// ApplyTextEdits applies byte-offset edits to s. The edits must be in
+// order and must not overlap; insertions at the same offset are applied
+// in order.
+func ApplyTextEdits(s string, edits []*TextEdit) (string, error) {
+	if err := checkTextEdits(edits, len(s)); err != nil {
+		return "", err
+	}
+	buf := new(bytes.Buffer)
+	pos := 0
+	for _, e := range edits {
+		buf.WriteString(s[pos:e.Start])
+		buf.WriteString(e.NewText)
+		pos = e.End
+	}
+	buf.WriteString(s[pos:])
+	return buf.String(), nil
+}
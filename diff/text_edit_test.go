@@ -0,0 +1,92 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"shanhu.io/third/diffmp"
+)
+
+func TestByteEdits(t *testing.T) {
+	a := SplitLines("package main\nfunc f() {\nreturn\n}")
+	b := SplitLines("package main\n\nfunc f() {\n\treturn\n}")
+	text1, text2 := strings.Join(a, ""), strings.Join(b, "")
+
+	lineEdits := ByteEdits(OpCodeEdits(NewMatcher(a, b).OpCodes(), a, b))
+	assertEqual(t, lineEdits, []*TextEdit{
+		{13, 13, "\n"},
+		{24, 24, "\t"},
+	})
+	charEdits := ByteEdits(DiffEdits(
+		diffmp.New().DiffMain(text1, text2, false),
+	))
+	for _, edits := range [][]*TextEdit{lineEdits, charEdits} {
+		got, err := ApplyTextEdits(text1, edits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, got, text2)
+	}
+
+	// Different invalid bytes are not trimmed as equal.
+	edits := ByteEdits(DiffEdits([]diffmp.Diff{
+		{Type: diffmp.Noop, Text: "a"},
+		{Type: diffmp.Delete, Text: "\xff"},
+		{Type: diffmp.Insert, Text: "\xfe"},
+		{Type: diffmp.Noop, Text: "b"},
+	}))
+	assertEqual(t, edits, []*TextEdit{{1, 2, "\xfe"}})
+	got, err := ApplyTextEdits("a\xffb", edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, got, "a\xfeb")
+
+	for _, edits := range [][]*TextEdit{
+		{{5, 8, ""}, {6, 9, ""}},
+		{{5, 3, ""}},
+		{{5, 100, ""}},
+	} {
+		if _, err := ApplyTextEdits(text1, edits); err == nil {
+			t.Errorf("want error for edits %v", edits)
+		}
+	}
+}
+
+func TestLSPEdits(t *testing.T) {
+	text1 := "a😀b\nxyz\n"
+	text2 := "a😀B\nxyz\nw"
+	edits := ByteEdits(DiffEdits(diffmp.New().DiffMain(text1, text2, false)))
+	lsp, err := LSPEdits(text1, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, lsp, []*LSPTextEdit{{
+		Range: LSPRange{
+			Start: LSPPosition{Line: 0, Character: 3},
+			End:   LSPPosition{Line: 0, Character: 4},
+		},
+		NewText: "B",
+	}, {
+		Range: LSPRange{
+			Start: LSPPosition{Line: 2, Character: 0},
+			End:   LSPPosition{Line: 2, Character: 0},
+		},
+		NewText: "w",
+	}})
+
+	got, err := ApplyLSPEdits(text1, lsp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, got, text2)
+
+	_, err = ApplyLSPEdits(text1, []*LSPTextEdit{lsp[1], lsp[0]})
+	if err == nil {
+		t.Error("want error for edits out of order")
+	}
+	_, err = LSPEdits(text1, []*TextEdit{{2, 3, ""}})
+	if err == nil {
+		t.Error("want error for an offset in a character")
+	}
+}
@@ -38,11 +38,11 @@ func formatRangeUnified(start, stop int) string {
 // times.  Any or all of these may be specified using strings for 'fromfile',
 // 'tofile', 'fromfiledate', and 'tofiledate'.  The modification times are
 // normally expressed in the ISO 8601 format.
+//
+// If either file is binary, only a "Binary files ... differ" line is
+// written, like GNU diff does. Use WriteGitBinaryDiff for a patch that
+// carries binary changes.
 func WriteUnifiedDiff(writer io.Writer, in *Input) error {
-	wf := func(format string, args ...interface{}) error {
-		_, err := fmt.Fprintf(writer, format, args...)
-		return err
-	}
 	ws := func(s string) error {
 		_, err := fmt.Fprint(writer, s)
 		return err
@@ -52,16 +52,28 @@ func WriteUnifiedDiff(writer io.Writer, in *Input) error {
 		in.Eol = "\n"
 	}
 
-	m := NewMatcher(in.A.Lines, in.B.Lines)
-	codes := m.GroupedOpCodes(in.Context)
+	if isBinary, differs := binaryDiffers(in); isBinary {
+		if differs {
+			return writeBinaryDiffers(writer, in)
+		}
+		return nil
+	}
+
+	codes := inputGroupedOpCodes(in)
+	colors := in.Colors
+	var spans *lineSpans
+	if colors != nil {
+		spans = inputSpans(in, codes)
+	}
 
 	if len(codes) > 0 {
 		if in.A.Name != "" || in.B.Name != "" {
-			err := wf("--- %s%s", in.A.title(), in.Eol)
+			h := colors.header()
+			err := ws(paint(h, "--- "+in.A.title()+in.Eol))
 			if err != nil {
 				return err
 			}
-			err = wf("+++ %s%s", in.B.title(), in.Eol)
+			err = ws(paint(h, "+++ "+in.B.title()+in.Eol))
 			if err != nil {
 				return err
 			}
@@ -72,13 +84,14 @@ func WriteUnifiedDiff(writer io.Writer, in *Input) error {
 		first, last := g[0], g[len(g)-1]
 		range1 := formatRangeUnified(first.I1, last.I2)
 		range2 := formatRangeUnified(first.J1, last.J2)
-		if err := wf("@@ -%s +%s @@%s", range1, range2, in.Eol); err != nil {
+		head := fmt.Sprintf("@@ -%s +%s @@%s", range1, range2, in.Eol)
+		if err := ws(paint(colors.hunk(), head)); err != nil {
 			return err
 		}
 		for _, c := range g {
 			i1, i2, j1, j2 := c.I1, c.I2, c.J1, c.J2
 			if c.Tag == 'e' {
-				for _, line := range in.A.slice(i1, i2) {
+				for _, line := range in.A.outLines(i1, i2) {
 					if err := ws(" " + line); err != nil {
 						return err
 					}
@@ -86,15 +99,23 @@ func WriteUnifiedDiff(writer io.Writer, in *Input) error {
 				continue
 			}
 			if c.Tag == 'r' || c.Tag == 'd' {
-				for _, line := range in.A.slice(i1, i2) {
-					if err := ws("-" + line); err != nil {
+				for k, line := range in.A.outLines(i1, i2) {
+					err := ws(paintSpans(
+						colors.delete(), colors.deleteSpan(),
+						"-", line, spans.spansA(i1+k),
+					))
+					if err != nil {
 						return err
 					}
 				}
 			}
 			if c.Tag == 'r' || c.Tag == 'i' {
-				for _, line := range in.B.slice(j1, j2) {
-					if err := ws("+" + line); err != nil {
+				for k, line := range in.B.outLines(j1, j2) {
+					err := ws(paintSpans(
+						colors.insert(), colors.insertSpan(),
+						"+", line, spans.spansB(j1+k),
+					))
+					if err != nil {
 						return err
 					}
 				}
@@ -0,0 +1,240 @@
+package diffmp
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// anchorContext is the length in bytes of the text kept on each side of
+// an anchor.
+const anchorContext = 32
+
+// Anchor is a range of a text that an annotation is attached to. It keeps
+// the text of the range and the text around it, so that the range can be
+// found again when the text changes.
+type Anchor struct {
+	// Start and End are the byte offsets of the range.
+	Start, End int
+
+	// Text is the text of the range, and Prefix and Suffix are the text
+	// right before and after it.
+	Text   string
+	Prefix string
+	Suffix string
+}
+
+// NewAnchor creates an anchor on the range of s from byte offset start to
+// end.
+func NewAnchor(s string, start, end int) (*Anchor, error) {
+	if start < 0 || end < start || end > len(s) {
+		return nil, fmt.Errorf("invalid range [%d, %d)", start, end)
+	}
+	for _, off := range []int{start, end} {
+		if off < len(s) && !utf8.RuneStart(s[off]) {
+			return nil, fmt.Errorf("offset %d: %s", off, errSplitChar)
+		}
+	}
+	return makeAnchor(s, start, end), nil
+}
+
+// makeAnchor creates an anchor on a valid range of s.
+func makeAnchor(s string, start, end int) *Anchor {
+	pre := max(0, start-anchorContext)
+	for pre > 0 && !utf8.RuneStart(s[pre]) {
+		pre--
+	}
+	post := min(len(s), end+anchorContext)
+	for post < len(s) && !utf8.RuneStart(s[post]) {
+		post++
+	}
+	return &Anchor{
+		Start:  start,
+		End:    end,
+		Text:   s[start:end],
+		Prefix: s[pre:start],
+		Suffix: s[end:post],
+	}
+}
+
+// snapRange widens a range of s to whole characters. An empty range
+// stays empty, at the start of the character that it is in.
+func snapRange(s string, start, end int) (int, int) {
+	empty := start == end
+	for start > 0 && start < len(s) && !utf8.RuneStart(s[start]) {
+		start--
+	}
+	if empty {
+		return start, start
+	}
+	for end < len(s) && !utf8.RuneStart(s[end]) {
+		end++
+	}
+	return start, end
+}
+
+// AnchorStatus tells how an anchor is found in a changed text.
+type AnchorStatus int
+
+// Anchor statuses.
+const (
+	// AnchorExact is an anchor whose text is unchanged and in the same
+	// place.
+	AnchorExact AnchorStatus = iota
+
+	// AnchorMoved is an anchor whose text is unchanged, but moved by the
+	// edits before it.
+	AnchorMoved
+
+	// AnchorFuzzy is an anchor whose text was changed, and is found again
+	// with a fuzzy match of the text and its context.
+	AnchorFuzzy
+
+	// AnchorOrphaned is an anchor that can not be found anymore, or whose
+	// text is all gone. Its range is where its start maps to, and is empty.
+	AnchorOrphaned
+)
+
+func (s AnchorStatus) String() string {
+	switch s {
+	case AnchorExact:
+		return "exact"
+	case AnchorMoved:
+		return "moved"
+	case AnchorFuzzy:
+		return "fuzzy"
+	case AnchorOrphaned:
+		return "orphaned"
+	}
+	return fmt.Sprintf("AnchorStatus(%d)", int(s))
+}
+
+// AnchorResult is an anchor remapped into a changed text.
+type AnchorResult struct {
+	// Anchor is the anchor in the changed text, with new context.
+	*Anchor
+
+	Status AnchorStatus
+
+	// Confidence is 1 for exact and moved anchors, 0 for orphaned ones,
+	// and between 0 and 1 for fuzzy ones, where it is 1 less the Bitap
+	// score of the match.
+	Confidence float64
+}
+
+// findAnchor finds the anchor fuzzily in s near loc, and returns the
+// range and the score of the match. It returns -1 when the anchor is not
+// found.
+func findAnchor(dmp *DMP, s string, a *Anchor, loc int) (
+	int, int, float64,
+) {
+	pattern := a.Prefix + a.Text + a.Suffix
+	if pattern == "" {
+		return -1, -1, 1
+	}
+	start, score := matchMain(dmp, s, pattern, loc-len(a.Prefix))
+	if start < 0 {
+		return -1, -1, score
+	}
+
+	// The match may be longer or shorter than the pattern; diff them to
+	// find the range in the match.
+	end := min(len(s), start+len(pattern)+len(pattern)/2)
+	for end < len(s) && !utf8.RuneStart(s[end]) {
+		end++
+	}
+	diffs := dmp.DiffMain(pattern, s[start:end], false)
+	n := len(a.Prefix)
+	rangeStart := start + NewPosMap(diffs).Map(n, BiasRight)
+	rangeEnd := start + anchorEnd(diffs, n+len(a.Text))
+	return rangeStart, max(rangeStart, rangeEnd), score
+}
+
+// anchorEnd maps the end of a range from text1 to text2 of diffs. Text
+// inserted at the end is not taken into the range, unless it replaces
+// text deleted from the end of the range.
+func anchorEnd(diffs []Diff, p int) int {
+	pos1, pos2 := 0, 0
+	for i, d := range diffs {
+		n := len(d.Text)
+		switch d.Type {
+		case Noop:
+			if p <= pos1+n {
+				return pos2 + p - pos1
+			}
+			pos1 += n
+			pos2 += n
+		case Delete:
+			if p <= pos1 {
+				return pos2
+			}
+			pos1 += n
+			if p <= pos1 {
+				if i+1 < len(diffs) && diffs[i+1].Type == Insert {
+					pos2 += len(diffs[i+1].Text)
+				}
+				return pos2
+			}
+		case Insert:
+			pos2 += n
+		}
+	}
+	return pos2
+}
+
+// RemapAnchor maps an anchor through diffs that change the text of the
+// anchor into a new text. The range is first mapped through the diffs.
+// If the text of the range is changed, the anchor is searched for near
+// there with its context, using the matching settings of dmp.
+func (dmp *DMP) RemapAnchor(a *Anchor, diffs []Diff) *AnchorResult {
+	return remapAnchor(dmp, NewPosMap(diffs), DiffText2(diffs), a)
+}
+
+// RemapAnchors maps anchors through diffs like RemapAnchor, but only
+// builds the position map once.
+func (dmp *DMP) RemapAnchors(as []*Anchor, diffs []Diff) []*AnchorResult {
+	m := NewPosMap(diffs)
+	s := DiffText2(diffs)
+	ret := make([]*AnchorResult, len(as))
+	for i, a := range as {
+		ret[i] = remapAnchor(dmp, m, s, a)
+	}
+	return ret
+}
+
+func remapAnchor(dmp *DMP, m *PosMap, s string, a *Anchor) *AnchorResult {
+	// The offsets of an anchor of another text may map into the middle of
+	// a character.
+	start, end, _ := m.MapRange(a.Start, a.End)
+	start, end = snapRange(s, start, end)
+	if s[start:end] == a.Text {
+		status := AnchorMoved
+		if start == a.Start {
+			status = AnchorExact
+		}
+		return &AnchorResult{
+			Anchor:     makeAnchor(s, start, end),
+			Status:     status,
+			Confidence: 1,
+		}
+	}
+
+	fstart, fend, score := findAnchor(dmp, s, a, start)
+	if fstart >= 0 {
+		fstart, fend = snapRange(s, fstart, fend)
+	}
+	if fstart < 0 || fstart == fend && a.Text != "" {
+		return &AnchorResult{
+			Anchor: makeAnchor(s, start, start),
+			Status: AnchorOrphaned,
+		}
+	}
+	confidence := 1 - score
+	if confidence < 0 {
+		confidence = 0
+	}
+	return &AnchorResult{
+		Anchor:     makeAnchor(s, fstart, fend),
+		Status:     AnchorFuzzy,
+		Confidence: confidence,
+	}
+}
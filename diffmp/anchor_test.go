@@ -0,0 +1,108 @@
+package diffmp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestNewAnchor(t *testing.T) {
+	s := "The quick brown fox jumps over the lazy dog."
+	a, err := NewAnchor(s, 10, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "brown", a.Text)
+	assert.Equal(t, "The quick ", a.Prefix)
+	assert.Equal(t, " fox jumps over the lazy dog.", a.Suffix)
+
+	_, err = NewAnchor(s, 10, 100)
+	assert.NotNil(t, err)
+	_, err = NewAnchor("日本", 1, 3)
+	assert.NotNil(t, err)
+}
+
+func remapOne(t *testing.T, text1, text2 string, start, end int) (
+	*AnchorResult, string,
+) {
+	dmp := New()
+	a, err := NewAnchor(text1, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := dmp.RemapAnchor(a, dmp.DiffMain(text1, text2, false))
+	return r, text2[r.Start:r.End]
+}
+
+func TestRemapAnchor(t *testing.T) {
+	text1 := "The quick brown fox jumps over the lazy dog."
+
+	r, got := remapOne(t, text1, text1+" The end.", 10, 15)
+	assert.Equal(t, AnchorExact, r.Status)
+	assert.Equal(t, "brown", got)
+	assert.Equal(t, 1.0, r.Confidence)
+
+	r, got = remapOne(t, text1, "Oh! "+text1, 10, 15)
+	assert.Equal(t, AnchorMoved, r.Status)
+	assert.Equal(t, 14, r.Start)
+	assert.Equal(t, "brown", got)
+	assert.Equal(t, "Oh! The quick ", r.Prefix)
+
+	text2 := "The quick brOwn fox jumps over the lazy dog."
+	r, got = remapOne(t, text1, text2, 10, 15)
+	assert.Equal(t, AnchorFuzzy, r.Status)
+	assert.Equal(t, "brOwn", got)
+	assert.True(t, r.Confidence > 0 && r.Confidence < 1)
+
+	text2 = "The quick fox jumps over the lazy dog."
+	r, got = remapOne(t, text1, text2, 10, 16)
+	assert.Equal(t, AnchorOrphaned, r.Status)
+	assert.Equal(t, "", got)
+	assert.Equal(t, 0.0, r.Confidence)
+
+	r, _ = remapOne(t, text1, "Something else entirely.", 10, 15)
+	assert.Equal(t, AnchorOrphaned, r.Status)
+}
+
+func TestRemapAnchorsUnicode(t *testing.T) {
+	dmp := New()
+	text1 := strings.Repeat("日本語。", 3) + "δέλτα alpha"
+	text2 := "前に。" + strings.Replace(text1, "δέλτα", "δέλτά", 1)
+	i := strings.Index(text1, "δέλτα")
+	j := strings.Index(text1, "alpha")
+	as := make([]*Anchor, 0, 2)
+	for _, r := range [][2]int{{i, i + len("δέλτα")}, {j, j + 5}} {
+		a, err := NewAnchor(text1, r[0], r[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		as = append(as, a)
+	}
+	rs := dmp.RemapAnchors(as, dmp.DiffMain(text1, text2, false))
+	assert.Equal(t, AnchorFuzzy, rs[0].Status)
+	assert.Equal(t, "δέλτά", rs[0].Text)
+	assert.Equal(t, AnchorMoved, rs[1].Status)
+	assert.Equal(t, "alpha", rs[1].Text)
+	assert.Equal(t, "fuzzy", rs[0].Status.String())
+}
+
+func TestRemapAnchorSplitChar(t *testing.T) {
+	dmp := New()
+
+	// An anchor of another text maps into the middle of a character.
+	a, err := NewAnchor("ab", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := dmp.RemapAnchor(a, dmp.DiffMain("éxyz", "éxyzw", false))
+	assert.Equal(t, AnchorOrphaned, r.Status)
+	assert.Equal(t, 0, r.Start)
+	assert.Equal(t, 0, r.End)
+
+	r = dmp.RemapAnchor(&Anchor{Start: 1, End: 1}, []Diff{{Noop, "日本"}})
+	assert.Equal(t, AnchorMoved, r.Status)
+	assert.Equal(t, 0, r.Start)
+	assert.Equal(t, 0, r.End)
+	assert.Equal(t, "日本", r.Suffix)
+}
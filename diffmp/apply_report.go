@@ -0,0 +1,77 @@
+package diffmp
+
+// ApplyFailure is the reason why a patch is not applied.
+type ApplyFailure int
+
+// Reasons why a patch is not applied.
+const (
+	// ApplyOK means the patch is applied.
+	ApplyOK ApplyFailure = iota
+
+	// ApplyNoMatch means the text of the patch is not found near the
+	// expected location.
+	ApplyNoMatch
+
+	// ApplyNoTrailingContext means the start of a long patch is found,
+	// but not its end.
+	ApplyNoTrailingContext
+
+	// ApplyDeleteThreshold means the text of a long patch is found, but
+	// differs from the patch by more than PatchDeleteThreshold.
+	ApplyDeleteThreshold
+
+	// ApplyScoreThreshold means the text of the patch is found, but the
+	// Bitap score of the match is above ApplyOptions.MaxScore.
+	ApplyScoreThreshold
+)
+
+func (f ApplyFailure) String() string {
+	switch f {
+	case ApplyOK:
+		return "ok"
+	case ApplyNoMatch:
+		return "no match"
+	case ApplyNoTrailingContext:
+		return "no trailing context"
+	case ApplyDeleteThreshold:
+		return "delete threshold exceeded"
+	case ApplyScoreThreshold:
+		return "score threshold exceeded"
+	}
+	return "unknown"
+}
+
+// ApplyOptions are options of ApplyWithReport.
+type ApplyOptions struct {
+	// MaxScore rejects fuzzy matches whose Bitap score is above it. The
+	// score is 0 for an exact match at the expected location, and grows
+	// with the errors and the distance of the match; see MatchThreshold
+	// and MatchDistance. 0 means no limit other than MatchThreshold.
+	MaxScore float64
+}
+
+// PatchReport is the result of applying one patch.
+type PatchReport struct {
+	Applied bool
+	Failure ApplyFailure
+
+	// ExpectedLoc is where the patch is expected in the text, shifted by
+	// the patches before it. Loc is where the patch is found, or -1 if it
+	// is not found. Both are byte offsets in the text that the patches
+	// are applied to, as it is before this patch is applied. A patch with
+	// context at the start of the text has locations before the text;
+	// they are reported as 0.
+	ExpectedLoc int
+	Loc         int
+
+	// Offset is how far the patch is found from where it is expected.
+	Offset int
+
+	// Score is the Bitap score of the match, when it is found.
+	Score float64
+
+	// LevenshteinRatio is the Levenshtein distance between the text of
+	// the patch and the matched text, divided by the length of the patch
+	// text. It is 0 for a perfect match.
+	LevenshteinRatio float64
+}
@@ -0,0 +1,75 @@
+package diffmp
+
+import (
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestApplyWithReport(t *testing.T) {
+	dmp := New()
+	patches := dmp.PatchMake(
+		"The quick brown fox jumps over the lazy dog.",
+		"That quick brown fox jumped over a lazy dog.",
+	)
+
+	got, reports := dmp.ApplyWithReport(
+		patches, "The quick brown fox jumps over the lazy dog.", nil,
+	)
+	assert.Equal(t, "That quick brown fox jumped over a lazy dog.", got)
+	assert.Equal(t, 2, len(reports))
+	for _, r := range reports {
+		assert.True(t, r.Applied)
+		assert.Equal(t, ApplyOK, r.Failure)
+		assert.Equal(t, 0, r.Offset)
+		assert.Equal(t, 0.0, r.Score)
+		assert.Equal(t, 0.0, r.LevenshteinRatio)
+	}
+	assert.Equal(t, 0, reports[0].Loc)
+
+	// Shifted text: found away from where it is expected.
+	_, reports = dmp.ApplyWithReport(
+		patches, "Preface. The quick brown fox jumps over the lazy dog.", nil,
+	)
+	assert.True(t, reports[0].Applied)
+	assert.Equal(t, 9, reports[0].Offset)
+	assert.True(t, reports[0].Score > 0)
+
+	// Fuzzy match, rejected with a low score limit.
+	text := "The quick red rabbit jumps over the tired tiger."
+	got, reports = dmp.ApplyWithReport(patches, text, nil)
+	assert.Equal(t, "That quick red rabbit jumped over a tired tiger.", got)
+	assert.True(t, reports[0].LevenshteinRatio > 0)
+	score := reports[0].Score
+	assert.True(t, score > 0)
+
+	got, reports = dmp.ApplyWithReport(
+		patches, text, &ApplyOptions{MaxScore: score / 2},
+	)
+	assert.Equal(t, ApplyScoreThreshold, reports[0].Failure)
+	assert.False(t, reports[0].Applied)
+	assert.Equal(t, "The quick red rabbit", got[:20])
+
+	// No match at all.
+	_, reports = dmp.ApplyWithReport(
+		patches, "I am the very model of a modern major general.", nil,
+	)
+	assert.Equal(t, ApplyNoMatch, reports[0].Failure)
+	assert.Equal(t, -1, reports[0].Loc)
+}
+
+func TestApplyWithReportDelete(t *testing.T) {
+	dmp := New()
+	old := "x1234567890123456789012345678901234567890" +
+		"123456789012345678901234567890y"
+	patches := dmp.PatchMake(old, "xabcy")
+	dmp.PatchDeleteThreshold = 0.1
+	_, reports := dmp.ApplyWithReport(
+		patches, "x123456789012345678901234567890-----+++++-----"+
+			"123456789012345678901234567890y", nil,
+	)
+	assert.Equal(t, ApplyDeleteThreshold, reports[0].Failure)
+	assert.True(t, reports[0].LevenshteinRatio > dmp.PatchDeleteThreshold)
+
+	assert.Equal(t, "no trailing context", ApplyNoTrailingContext.String())
+}
@@ -0,0 +1,56 @@
+package diffmp
+
+// bitVector is a bit set of any width for the Bitap algorithm. Bit i is
+// bit i%64 of word i/64.
+type bitVector []uint64
+
+func bitVectorWords(nbit int) int { return (nbit + 63) / 64 }
+
+func (v bitVector) set(i int) { v[i/64] |= 1 << uint(i%64) }
+
+func (v bitVector) has(i int) bool { return v[i/64]&(1<<uint(i%64)) != 0 }
+
+// setLow sets the lowest n bits and clears the others.
+func (v bitVector) setLow(n int) {
+	for i := range v {
+		switch {
+		case n >= 64:
+			v[i] = ^uint64(0)
+			n -= 64
+		case n > 0:
+			v[i] = 1<<uint(n) - 1
+			n = 0
+		default:
+			v[i] = 0
+		}
+	}
+}
+
+// shiftOr1 sets v to (a << 1) | 1.
+func (v bitVector) shiftOr1(a bitVector) {
+	carry := uint64(1)
+	for i, w := range a {
+		v[i] = w<<1 | carry
+		carry = w >> 63
+	}
+}
+
+// and sets v to v & a. A nil a clears v.
+func (v bitVector) and(a bitVector) {
+	if a == nil {
+		for i := range v {
+			v[i] = 0
+		}
+		return
+	}
+	for i, w := range a {
+		v[i] &= w
+	}
+}
+
+// or sets v to v | a.
+func (v bitVector) or(a bitVector) {
+	for i, w := range a {
+		v[i] |= w
+	}
+}
@@ -2,14 +2,13 @@ package diffmp
 
 import (
 	"math"
+	"sort"
 )
 
 // matchBitapScore computes and returns the score for a match with e errors
-// and x location.
-func matchBitapScore(
-	dmp *DMP, e, x, loc int, pattern string,
-) float64 {
-	accuracy := float64(e) / float64(len(pattern))
+// and x location, for a pattern of n runes.
+func matchBitapScore(dmp *DMP, e, x, loc, n int) float64 {
+	accuracy := float64(e) / float64(n)
 	proximity := float64(abs(loc - x))
 	if dmp.MatchDistance == 0 {
 		// Dodge divide by zero error.
@@ -21,46 +20,82 @@ func matchBitapScore(
 	return accuracy + (proximity / float64(dmp.MatchDistance))
 }
 
-func matchBitap(dmp *DMP, text, pattern string, loc int) int {
+// textRunes decodes text into runes. It also returns the byte offset of
+// each rune, followed by len(text).
+func textRunes(text string) ([]rune, []int) {
+	runes := make([]rune, 0, len(text))
+	offsets := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		runes = append(runes, r)
+		offsets = append(offsets, i)
+	}
+	return runes, append(offsets, len(text))
+}
+
+// matchBitap works on runes, so that a fuzzy match never starts in the
+// middle of a UTF-8 sequence, and errors and distances are counted in
+// runes. loc and the result are still byte offsets. It also returns the
+// score of the match.
+func matchBitap(dmp *DMP, text, pattern string, loc int) (int, float64) {
+	t, offsets := textRunes(text)
+	loc = max(0, min(loc, len(text)))
+	// The rune that contains the byte at loc.
+	rloc := sort.SearchInts(offsets, loc+1) - 1
+	ret, score := matchBitapRunes(dmp, t, []rune(pattern), rloc)
+	if ret < 0 {
+		return -1, score
+	}
+	return offsets[min(ret, len(t))], score
+}
+
+func matchBitapRunes(dmp *DMP, text, pattern []rune, loc int) (
+	int, float64,
+) {
+	m := len(pattern)
+	if m == 0 {
+		return loc, 0
+	}
+
 	// Initialise the alphabet.
-	s := MatchAlphabet(pattern)
+	s := matchAlphabet(pattern)
 
 	// Highest score beyond which we give up.
 	threshold := float64(dmp.MatchThreshold)
 	// Is there a nearby exact match? (speedup)
-	bestLoc := indexOf(text, pattern, loc)
+	bestLoc := runesIndexOf(text, pattern, loc)
 	if bestLoc != -1 {
 		threshold = math.Min(
-			matchBitapScore(dmp, 0, bestLoc, loc, pattern),
-			threshold,
+			matchBitapScore(dmp, 0, bestLoc, loc, m), threshold,
 		)
 		// What about in the other direction? (speedup)
-		bestLoc = lastIndexOf(text, pattern, loc+len(pattern))
+		bestLoc = runesLastIndexOf(text, pattern, loc+m)
 		if bestLoc != -1 {
 			threshold = math.Min(
-				matchBitapScore(dmp, 0, bestLoc, loc, pattern),
-				threshold,
+				matchBitapScore(dmp, 0, bestLoc, loc, m), threshold,
 			)
 		}
 	}
 
-	// Initialise the bit arrays.
-	matchmask := 1 << uint((len(pattern) - 1))
+	// Initialise the bit arrays. Each array holds a bit vector of w words
+	// for each position in the text.
+	w := bitVectorWords(m)
+	vec := func(a []uint64, j int) bitVector {
+		return bitVector(a[j*w : (j+1)*w])
+	}
+	tmp := make(bitVector, w)
 	bestLoc = -1
 
 	var binMin, binMid int
-	binMax := len(pattern) + len(text)
-	lastRD := []int{}
-	for d := 0; d < len(pattern); d++ {
+	binMax := m + len(text)
+	var lastRD []uint64
+	for d := 0; d < m; d++ {
 		// Scan for the best match; each iteration allows for one more error.
 		// Run a binary search to determine how far from 'loc' we can stray at
 		// this error level.
 		binMin = 0
 		binMid = binMax
 		for binMin < binMid {
-			if matchBitapScore(
-				dmp, d, loc+binMid, loc, pattern,
-			) <= threshold {
+			if matchBitapScore(dmp, d, loc+binMid, loc, m) <= threshold {
 				binMin = binMid
 			} else {
 				binMax = binMid
@@ -70,32 +105,30 @@ func matchBitap(dmp *DMP, text, pattern string, loc int) int {
 		// Use the result from this iteration as the maximum for the next.
 		binMax = binMid
 		start := max(1, loc-binMid+1)
-		finish := min(loc+binMid, len(text)) + len(pattern)
+		finish := min(loc+binMid, len(text)) + m
 
-		rd := make([]int, finish+2)
-		rd[finish+1] = (1 << uint(d)) - 1
+		rd := make([]uint64, (finish+2)*w)
+		vec(rd, finish+1).setLow(d)
 
 		for j := finish; j >= start; j-- {
-			var charMatch int
-			if len(text) <= j-1 {
-				// Out of range.
-				charMatch = 0
-			} else if _, ok := s[text[j-1]]; !ok {
-				charMatch = 0
-			} else {
+			var charMatch bitVector
+			if j-1 < len(text) {
 				charMatch = s[text[j-1]]
 			}
 
-			if d == 0 {
-				// First pass: exact match.
-				rd[j] = ((rd[j+1] << 1) | 1) & charMatch
-			} else {
+			cur := vec(rd, j)
+			cur.shiftOr1(vec(rd, j+1))
+			cur.and(charMatch)
+			if d > 0 {
 				// Subsequent passes: fuzzy match.
-				rd[j] = ((rd[j+1]<<1)|1)&charMatch |
-					(((lastRD[j+1] | lastRD[j]) << 1) | 1) | lastRD[j+1]
+				copy(tmp, vec(lastRD, j+1))
+				tmp.or(vec(lastRD, j))
+				tmp.shiftOr1(tmp)
+				cur.or(tmp)
+				cur.or(vec(lastRD, j+1))
 			}
-			if (rd[j] & matchmask) != 0 {
-				score := matchBitapScore(dmp, d, j-1, loc, pattern)
+			if cur.has(m - 1) {
+				score := matchBitapScore(dmp, d, j-1, loc, m)
 				// This match will almost certainly be better than any
 				// existing match.  But check anyway.
 				if score <= threshold {
@@ -113,11 +146,11 @@ func matchBitap(dmp *DMP, text, pattern string, loc int) int {
 				}
 			}
 		}
-		if matchBitapScore(dmp, d+1, loc, loc, pattern) > threshold {
+		if matchBitapScore(dmp, d+1, loc, loc, m) > threshold {
 			// No hope for a (better) match at greater error levels.
 			break
 		}
 		lastRD = rd
 	}
-	return bestLoc
+	return bestLoc, threshold
 }
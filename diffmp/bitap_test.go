@@ -0,0 +1,102 @@
+package diffmp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestMatchAlphabetRunes(t *testing.T) {
+	assert.Equal(t, map[rune][]uint64{
+		'日': {5},
+		'本': {2},
+	}, MatchAlphabetRunes("日本日"))
+
+	// Bit 64 is the lowest bit of the second word.
+	pattern := "x" + strings.Repeat("y", 64)
+	assert.Equal(t, map[rune][]uint64{
+		'x': {0, 1},
+		'y': {1<<64 - 1, 0},
+	}, MatchAlphabetRunes(pattern))
+}
+
+func TestMatchBitapUnicode(t *testing.T) {
+	dmp := New()
+	dmp.MatchDistance = 100
+	dmp.MatchThreshold = 0.5
+
+	// "日本語" takes 3 bytes per rune; results are byte offsets at rune
+	// starts.
+	text := "日本語のテキストです"
+	assert.Equal(t, 9, dmp.MatchBitap(text, "のテキ", 0))
+	assert.Equal(t, 9, dmp.MatchBitap(text, "のテギ", 10))
+	assert.Equal(t, 9, dmp.MatchMain(text, "のデキスト", 9))
+
+	// A one rune error is one error, not three byte errors.
+	dmp.MatchThreshold = 0.26
+	assert.Equal(t, 1, dmp.MatchBitap("x日本ご語y", "日本x語", 0))
+}
+
+func TestMatchBitapWide(t *testing.T) {
+	dmp := New()
+	dmp.MatchDistance = 1000
+	dmp.MatchThreshold = 0.5
+
+	var b strings.Builder
+	for i := 0; i < 40; i++ {
+		b.WriteString("the quick brown fox ")
+		b.WriteByte(byte('a' + i%26))
+	}
+	text := b.String()
+	pattern := text[300:500]
+	assert.Equal(t, 300, dmp.MatchBitap(text, pattern, 310))
+
+	fuzzy := pattern[:100] + "JUMPS" + pattern[105:]
+	assert.Equal(t, 300, dmp.MatchBitap(text, fuzzy, 290))
+	assert.Equal(t, -1, dmp.MatchBitap(text, strings.ToUpper(pattern), 300))
+}
+
+func TestApplyUnlimited(t *testing.T) {
+	dmp := New()
+	dmp.MatchMaxBits = 0
+
+	old := "x1234567890123456789012345678901234567890" +
+		"123456789012345678901234567890y"
+	patches := dmp.PatchMake(old, "xabcy")
+	assert.Equal(t, 1, len(patches))
+
+	got, results := dmp.Apply(patches, old)
+	assert.Equal(t, "xabcy", got)
+	assert.Equal(t, []bool{true}, results)
+
+	// Big delete with a small change: a single patch still applies.
+	got, results = dmp.Apply(
+		patches, "x123456789012345678901234567890-----+++++-----"+
+			"123456789012345678901234567890y",
+	)
+	assert.Equal(t, []bool{true}, results)
+	assert.Equal(t, "xabcy", got)
+
+	// Big delete with a big change: rejected by PatchDeleteThreshold.
+	text := "x12345678901234567890---------------++++++++++" +
+		"---------------12345678901234567890y"
+	got, results = dmp.Apply(patches, text)
+	assert.Equal(t, []bool{false}, results)
+	assert.Equal(t, text, got)
+
+	// Long unique context is kept in one piece.
+	var b strings.Builder
+	for i := 0; i < 30; i++ {
+		b.WriteString("line ")
+		b.WriteByte(byte('a' + i%26))
+		b.WriteByte(byte('0' + i/26))
+		b.WriteString("\n")
+	}
+	long := b.String()
+	changed := strings.Replace(long, "line m0", "LINE M0", 1)
+	patches = dmp.PatchMake(long, changed)
+	got, results = dmp.Apply(patches, "prefix\n"+long)
+	assert.Equal(t, "prefix\n"+changed, got)
+	assert.Equal(t, []bool{true}, results)
+}
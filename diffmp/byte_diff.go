@@ -0,0 +1,87 @@
+package diffmp
+
+// ByteDiff is one diff operation on raw bytes.
+type ByteDiff struct {
+	Type Op
+	Data []byte
+}
+
+func bytesToIDs(bs []byte) []int32 {
+	ids := make([]int32, len(bs))
+	for i, b := range bs {
+		ids[i] = int32(b)
+	}
+	return ids
+}
+
+func idsToBytes(ids []int32) []byte {
+	bs := make([]byte, len(ids))
+	for i, id := range ids {
+		bs[i] = byte(id)
+	}
+	return bs
+}
+
+// latin1 decodes bs as Latin-1, so that every byte is a rune in the
+// string. Strings in Latin-1 work with the text functions, and their
+// offsets in runes are the offsets in bytes.
+func latin1(bs []byte) string { return string(bytesToIDs(bs)) }
+
+func unlatin1(s string) []byte { return idsToBytes([]rune(s)) }
+
+// rawDiffs converts byte diffs into diffs with the raw bytes as text, which
+// might not be UTF-8.
+func rawDiffs(diffs []ByteDiff) []Diff {
+	ret := make([]Diff, len(diffs))
+	for i, d := range diffs {
+		ret[i] = Diff{d.Type, string(d.Data)}
+	}
+	return ret
+}
+
+func fromRawDiffs(diffs []Diff) []ByteDiff {
+	ret := make([]ByteDiff, len(diffs))
+	for i, d := range diffs {
+		ret[i] = ByteDiff{d.Type, []byte(d.Text)}
+	}
+	return ret
+}
+
+// DiffMainBytes finds the differences between two byte slices. It works
+// like DiffMain, byte by byte, and the bytes do not need to be UTF-8.
+// When checkLines is true, it first diffs lines that end with '\n'.
+func (dmp *DMP) DiffMainBytes(b1, b2 []byte, checkLines bool) []ByteDiff {
+	ids1, ids2 := bytesToIDs(b1), bytesToIDs(b2)
+	run := newDiffRun(nil, dmp)
+	diffs := diffMainIDs(dmp, ids1, ids2, checkLines, run)
+	ret := make([]ByteDiff, len(diffs))
+	for i, d := range diffs {
+		ret[i] = ByteDiff{d.Type, idsToBytes(d.IDs)}
+	}
+	return ret
+}
+
+// ByteDiffData1 computes the source bytes of the diffs.
+func ByteDiffData1(diffs []ByteDiff) []byte {
+	return []byte(DiffText1(rawDiffs(diffs)))
+}
+
+// ByteDiffData2 computes the destination bytes of the diffs.
+func ByteDiffData2(diffs []ByteDiff) []byte {
+	return []byte(DiffText2(rawDiffs(diffs)))
+}
+
+// ByteDiffToDelta works like DiffToDelta, but for byte diffs. Lengths are
+// in bytes, and inserted bytes are escaped one by one.
+func ByteDiffToDelta(diffs []ByteDiff) string {
+	return DiffToDeltaUnit(rawDiffs(diffs), unitRaw)
+}
+
+// ByteDiffFromDelta works like FromDelta, but for bytes.
+func ByteDiffFromDelta(bs []byte, delta string) ([]ByteDiff, error) {
+	diffs, err := FromDeltaUnit(string(bs), delta, unitRaw)
+	if err != nil {
+		return nil, err
+	}
+	return fromRawDiffs(diffs), nil
+}
@@ -0,0 +1,84 @@
+package diffmp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestDiffMainBytes(t *testing.T) {
+	dmp := New()
+	b1 := []byte{0xff, 0x00, 'a', 0x80, 0xfe}
+	b2 := []byte{0xff, 0x01, 'a', 0x80, 0xfe, 0xc3}
+	diffs := dmp.DiffMainBytes(b1, b2, false)
+	assert.Equal(t, []ByteDiff{
+		{Noop, []byte{0xff}},
+		{Delete, []byte{0x00}},
+		{Insert, []byte{0x01}},
+		{Noop, []byte{'a', 0x80, 0xfe}},
+		{Insert, []byte{0xc3}},
+	}, diffs)
+
+	delta := ByteDiffToDelta(diffs)
+	assert.Equal(t, "=1\t-1\t+%01\t=3\t+%C3", delta)
+	got, err := ByteDiffFromDelta(b1, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, diffs, got)
+	_, err = ByteDiffFromDelta(b1[1:], delta)
+	assert.NotNil(t, err)
+
+	// Line mode with binary lines.
+	line := bytes.Repeat([]byte{0xe9, 0x00, 0x80}, 20)
+	var l1, l2 [][]byte
+	for i := 0; i < 10; i++ {
+		l1 = append(l1, append([]byte{byte(i)}, line...))
+		l2 = append(l2, append([]byte{byte(i * 2)}, line...))
+	}
+	b1 = bytes.Join(l1, []byte("\n"))
+	b2 = bytes.Join(l2, []byte("\n"))
+	diffs = dmp.DiffMainBytes(b1, b2, true)
+	assert.Equal(t, b1, ByteDiffData1(diffs))
+	assert.Equal(t, b2, ByteDiffData2(diffs))
+}
+
+func TestBytePatch(t *testing.T) {
+	dmp := New()
+	// Latin-1 text.
+	b1 := []byte("Caf\xe9 cr\xe8me, tr\xe8s bon. " +
+		"Le g\xe2teau est d\xe9j\xe0 l\xe0.")
+	b2 := []byte("Caf\xe9 cr\xe8me, tr\xe8s bien. " +
+		"Le g\xe2teau n'est pas l\xe0.")
+	ps := dmp.PatchFromBytes(b1, b2)
+	assert.Equal(t, 2, len(ps))
+	assert.Equal(t, 14, ps[0].Start1())
+	assert.Equal(t, []ByteDiff{
+		{Noop, []byte("\xe8s b")},
+		{Delete, []byte("o")},
+		{Insert, []byte("ie")},
+		{Noop, []byte("n. L")},
+	}, ps[0].Diffs())
+
+	got, applied := dmp.ApplyBytes(ps, b1)
+	assert.Equal(t, b2, got)
+	assert.Equal(t, []bool{true, true}, applied)
+
+	text := BytePatchToText(ps)
+	assert.Equal(t,
+		"@@ -15,9 +15,10 @@\n %E8s b\n-o\n+ie\n n. L\n"+
+			"@@ -30,15 +30,16 @@\n eau \n+n'\n est \n-d%E9j%E0\n+pas\n"+
+			"  l%E0\n",
+		text,
+	)
+	parsed, err := BytePatchFromText(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ps, parsed)
+
+	// Patches still apply when the bytes have moved.
+	got, _ = dmp.ApplyBytes(parsed, append([]byte("\xab\xbb "), b1...))
+	assert.Equal(t, append([]byte("\xab\xbb "), b2...), got)
+}
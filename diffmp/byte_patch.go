@@ -0,0 +1,80 @@
+package diffmp
+
+// BytePatch is a patch on raw bytes. Its offsets and lengths are in bytes,
+// and the bytes do not need to be UTF-8.
+type BytePatch Patch
+
+// Diffs returns a copy of the diffs of the patch, including the context.
+func (p *BytePatch) Diffs() []ByteDiff { return fromRawDiffs(p.diffs) }
+
+// Start1 returns the byte offset of the patch in the original bytes.
+func (p *BytePatch) Start1() int { return p.start1 }
+
+// Start2 returns the byte offset of the patch in the patched bytes.
+func (p *BytePatch) Start2() int { return p.start2 }
+
+// Length1 returns the number of bytes that the patch changes, including
+// the context.
+func (p *BytePatch) Length1() int { return p.length1 }
+
+// Length2 returns the number of bytes after the patch, including the
+// context.
+func (p *BytePatch) Length2() int { return p.length2 }
+
+// String formats the patch like Patch.String.
+func (p *BytePatch) String() string { return (*Patch)(p).String() }
+
+// PatchFromBytes makes patches that turn b1 into b2.
+func (dmp *DMP) PatchFromBytes(b1, b2 []byte) []BytePatch {
+	text1 := latin1(b1)
+	ps := patchMake2(dmp, text1, dmp.patchDiffs(text1, latin1(b2)))
+	ps = patchesToUnit(ps, text1, UnitRunes)
+
+	ret := make([]BytePatch, len(ps))
+	for i, p := range ps {
+		for j, d := range p.diffs {
+			p.diffs[j].Text = string(unlatin1(d.Text))
+		}
+		ret[i] = BytePatch(p)
+	}
+	return ret
+}
+
+// ApplyBytes merges a set of byte patches onto bs, like Apply.
+func (dmp *DMP) ApplyBytes(ps []BytePatch, bs []byte) ([]byte, []bool) {
+	text := latin1(bs)
+	patches := make([]Patch, len(ps))
+	for i, p := range ps {
+		patches[i] = Patch(p)
+		patches[i].diffs = make([]Diff, len(p.diffs))
+		for j, d := range p.diffs {
+			t := latin1([]byte(d.Text))
+			patches[i].diffs[j] = Diff{d.Type, t}
+		}
+	}
+	patches = patchesFromUnit(patches, text, UnitRunes)
+	text, applied := dmp.Apply(patches, text)
+	return unlatin1(text), applied
+}
+
+// BytePatchToText works like PatchToText, but for byte patches.
+func BytePatchToText(ps []BytePatch) string {
+	patches := make([]Patch, len(ps))
+	for i, p := range ps {
+		patches[i] = Patch(p)
+	}
+	return PatchToText(patches)
+}
+
+// BytePatchFromText works like PatchFromText, but for byte patches.
+func BytePatchFromText(s string) ([]BytePatch, error) {
+	patches, err := PatchFromText(s)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]BytePatch, len(patches))
+	for i, p := range patches {
+		ret[i] = BytePatch(p)
+	}
+	return ret, nil
+}
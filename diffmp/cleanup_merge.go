@@ -34,7 +34,7 @@ func DiffCleanupMerge(ds []Diff) []Diff {
 			if ndel+nins > 1 {
 				if ndel != 0 && nins != 0 {
 					// Factor out any common prefixies.
-					commonlength = CommonPrefixLen(
+					commonlength = commonPrefixBytes(
 						insStr, delStr,
 					)
 					if commonlength != 0 {
@@ -55,7 +55,7 @@ func DiffCleanupMerge(ds []Diff) []Diff {
 						delStr = delStr[commonlength:]
 					}
 					// Factor out any common suffixies.
-					commonlength = CommonSuffixLen(
+					commonlength = commonSuffixBytes(
 						insStr, delStr,
 					)
 					if commonlength != 0 {
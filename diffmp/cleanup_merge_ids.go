@@ -0,0 +1,115 @@
+package diffmp
+
+func idsHasPrefix(s, prefix []int32) bool {
+	return len(s) >= len(prefix) && runesEqual(s[:len(prefix)], prefix)
+}
+
+func idsHasSuffix(s, suffix []int32) bool {
+	return len(s) >= len(suffix) &&
+		runesEqual(s[len(s)-len(suffix):], suffix)
+}
+
+// cleanupMergeIDs works like DiffCleanupMerge, but on token IDs. The ID
+// slices might share memory with the input, so they are never appended to
+// in place.
+func cleanupMergeIDs(ds []TokenDiff) []TokenDiff {
+	// Add a dummy entry at the end.
+	ds = append(ds, TokenDiff{Noop, nil})
+	i := 0
+	ndel := 0
+	nins := 0
+	var dels, ins []int32
+
+	for i < len(ds) {
+		switch ds[i].Type {
+		case Insert:
+			nins++
+			ins = append(ins, ds[i].IDs...)
+			i++
+		case Delete:
+			ndel++
+			dels = append(dels, ds[i].IDs...)
+			i++
+		case Noop:
+			// Upon reaching an equality, check for prior redundancies.
+			if ndel+nins > 1 {
+				if ndel != 0 && nins != 0 {
+					// Factor out any common prefixies.
+					if n := commonPrefixLen(ins, dels); n != 0 {
+						x := i - ndel - nins
+						if x > 0 && ds[x-1].Type == Noop {
+							ds[x-1].IDs = concat(ds[x-1].IDs, ins[:n])
+						} else {
+							ds = spliceIDs(ds, 0, 0, TokenDiff{Noop, ins[:n]})
+							i++
+						}
+						ins = ins[n:]
+						dels = dels[n:]
+					}
+					// Factor out any common suffixies.
+					if n := commonSuffixLen(ins, dels); n != 0 {
+						ds[i].IDs = concat(ins[len(ins)-n:], ds[i].IDs)
+						ins = ins[:len(ins)-n]
+						dels = dels[:len(dels)-n]
+					}
+				}
+				// Delete the offending records and add the merged ones.
+				var merged []TokenDiff
+				if ndel != 0 {
+					merged = append(merged, TokenDiff{Delete, dels})
+				}
+				if nins != 0 {
+					merged = append(merged, TokenDiff{Insert, ins})
+				}
+				ds = spliceIDs(ds, i-ndel-nins, ndel+nins, merged...)
+				i = i - ndel - nins + len(merged) + 1
+			} else if i != 0 && ds[i-1].Type == Noop {
+				// Merge this equality with the previous one.
+				ds[i-1].IDs = concat(ds[i-1].IDs, ds[i].IDs)
+				ds = spliceIDs(ds, i, 1)
+			} else {
+				i++
+			}
+			nins = 0
+			ndel = 0
+			dels = nil
+			ins = nil
+		}
+	}
+
+	if len(ds[len(ds)-1].IDs) == 0 {
+		ds = ds[:len(ds)-1] // Remove the dummy entry at the end.
+	}
+
+	// Second pass: look for single edits surrounded on both sides by
+	// equalities which can be shifted sideways to eliminate an equality.
+	// e.g: A<ins>BA</ins>C -> <ins>AB</ins>AC
+	changes := false
+	// Intentionally ignore the first and last element (don't need checking).
+	for i = 1; i < len(ds)-1; i++ {
+		if ds[i-1].Type != Noop || ds[i+1].Type != Noop {
+			continue
+		}
+		// This is a single edit surrounded by equalities.
+		prev, edit, next := ds[i-1].IDs, ds[i].IDs, ds[i+1].IDs
+		if idsHasSuffix(edit, prev) {
+			// Shift the edit over the previous equality.
+			ds[i].IDs = concat(prev, edit[:len(edit)-len(prev)])
+			ds[i+1].IDs = concat(prev, next)
+			ds = spliceIDs(ds, i-1, 1)
+			changes = true
+		} else if idsHasPrefix(edit, next) {
+			// Shift the edit over the next equality.
+			ds[i-1].IDs = concat(prev, next)
+			ds[i].IDs = concat(edit[len(next):], next)
+			ds = spliceIDs(ds, i+1, 1)
+			changes = true
+		}
+	}
+
+	// If shifts were made, the diff needs reordering and another shift sweep.
+	if changes {
+		ds = cleanupMergeIDs(ds)
+	}
+	return ds
+}
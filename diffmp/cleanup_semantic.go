@@ -76,7 +76,7 @@ func DiffCleanupSemanticLossless(diffs []Diff) []Diff {
 			equality2 := diffs[i+1].Text
 
 			// First, shift the edit as far left as possible.
-			commonOffset := CommonSuffixLen(equality1, edit)
+			commonOffset := commonSuffixBytes(equality1, edit)
 			if commonOffset > 0 {
 				commonString := edit[len(edit)-commonOffset:]
 				equality1 = equality1[0 : len(equality1)-commonOffset]
@@ -250,7 +250,7 @@ func DiffCleanupSemantic(diffs []Diff) []Diff {
 					// Reverse overlap found.
 					// Insert an equality and swap and trim the surrounding
 					// edits.
-					overlap := Diff{Noop, insertion[noverlap2:]}
+					overlap := Diff{Noop, deletion[:noverlap2]}
 					diffs = append(
 						diffs[:i],
 						append([]Diff{overlap}, diffs[i:]...)...)
@@ -0,0 +1,49 @@
+package diffmp
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func assertValidDiffs(t *testing.T, text1, text2 string, diffs []Diff) {
+	t.Helper()
+	for _, d := range diffs {
+		assert.True(t, utf8.ValidString(d.Text), "invalid %q", d.Text)
+	}
+	assert.Equal(t, text1, DiffText1(diffs))
+	assert.Equal(t, text2, DiffText2(diffs))
+}
+
+func TestDiffCleanupNonASCII(t *testing.T) {
+	// Common prefixes and suffixes are factored out in whole characters.
+	diffs := DiffCleanupMerge([]Diff{
+		{Delete, "日a本"},
+		{Insert, "日b本"}})
+	assertDiffEqual(t, []Diff{
+		{Noop, "日"},
+		{Delete, "a"},
+		{Insert, "b"},
+		{Noop, "本"}}, diffs)
+
+	// Edits are shifted by whole characters, here to a word boundary.
+	diffs = DiffCleanupSemanticLossless([]Diff{
+		{Noop, "a 日"},
+		{Insert, "b 日"},
+		{Noop, "本"}})
+	assertValidDiffs(t, "a 日本", "a 日b 日本", diffs)
+	assertDiffEqual(t, []Diff{
+		{Noop, "a "},
+		{Insert, "日b "},
+		{Noop, "日本"}}, diffs)
+
+	// Reverse overlap elimination.
+	diffs = DiffCleanupSemantic([]Diff{
+		{Delete, "日本ab"},
+		{Insert, "d日本"}})
+	assertDiffEqual(t, []Diff{
+		{Insert, "d"},
+		{Noop, "日本"},
+		{Delete, "ab"}}, diffs)
+}
@@ -2,6 +2,7 @@ package diffmp
 
 import (
 	"strings"
+	"unicode/utf8"
 )
 
 // commonPrefixLen returns the length of the common prefix of two rune
@@ -43,6 +44,35 @@ func CommonSuffixLen(s1, s2 string) int {
 	return commonSuffixLen([]rune(s1), []rune(s2))
 }
 
+// commonPrefixBytes returns the length in bytes of the common prefix of two
+// strings, never splitting a character.
+func commonPrefixBytes(s1, s2 string) int {
+	n := min(len(s1), len(s2))
+	i := 0
+	for i < n && s1[i] == s2[i] {
+		i++
+	}
+	for i > 0 && i < len(s1) && !utf8.RuneStart(s1[i]) {
+		i--
+	}
+	return i
+}
+
+// commonSuffixBytes returns the length in bytes of the common suffix of two
+// strings, never splitting a character.
+func commonSuffixBytes(s1, s2 string) int {
+	n1, n2 := len(s1), len(s2)
+	n := min(n1, n2)
+	i := 0
+	for i < n && s1[n1-1-i] == s2[n2-1-i] {
+		i++
+	}
+	for i > 0 && !utf8.RuneStart(s1[n1-i]) {
+		i--
+	}
+	return i
+}
+
 // CommonOverlap determines if the suffix of one string is the prefix of
 // another.
 func CommonOverlap(s1, s2 string) int {
@@ -56,6 +56,14 @@ func TestCommonOverlap(t *testing.T) {
 	assert.Equal(t, 0, CommonOverlap("fi", "\ufb01i"), "")
 }
 
+func TestCommonBytes(t *testing.T) {
+	assert.Equal(t, 4, commonPrefixBytes("1234abc", "1234xyz"))
+	assert.Equal(t, 3, commonPrefixBytes("日本", "日木"))
+	assert.Equal(t, 3, commonSuffixBytes("abc", "xabc"))
+	assert.Equal(t, 0, commonSuffixBytes("\u00e9", "\u00a9"))
+	assert.Equal(t, 3, commonSuffixBytes("a\u00e9x", "b\u00e9x"))
+}
+
 func BenchmarkCommonPrefixLen(b *testing.B) {
 	a := "ABCDEFGHIJKLMNOPQRSTUVWXYZÅÄÖ"
 	for i := 0; i < b.N; i++ {
@@ -0,0 +1,195 @@
+package diffmp
+
+import (
+	"fmt"
+)
+
+// spanCursor walks a span list, and can take part of a span.
+type spanCursor struct {
+	spans []patchSpan
+	i     int
+	off   int // bytes of spans[i] already taken
+}
+
+func (c *spanCursor) done() bool { return c.i >= len(c.spans) }
+
+// edit returns the operation of the current span, or Noop for a gap.
+func (c *spanCursor) edit() Op {
+	if c.done() || c.spans[c.i].isGap() {
+		return Noop
+	}
+	return c.spans[c.i].Type
+}
+
+// left returns the bytes left in the current span, or spanRest.
+func (c *spanCursor) left() int {
+	s := &c.spans[c.i]
+	if s.gap == spanRest {
+		return spanRest
+	}
+	if s.isGap() {
+		return s.gap - c.off
+	}
+	return len(s.Text) - c.off
+}
+
+// take takes n bytes of the current span. A gap that covers the rest of
+// the text is never used up.
+func (c *spanCursor) take(n int) patchSpan {
+	s := &c.spans[c.i]
+	if s.isGap() {
+		if s.gap != spanRest {
+			c.skip(n, s.gap)
+		}
+		return patchSpan{gap: n}
+	}
+	ret := patchSpan{Diff: Diff{s.Type, s.Text[c.off : c.off+n]}}
+	c.skip(n, len(s.Text))
+	return ret
+}
+
+func (c *spanCursor) skip(n, size int) {
+	c.off += n
+	if c.off >= size {
+		c.i++
+		c.off = 0
+	}
+}
+
+// composeSpans composes spans a that turn text1 into text2 and spans b
+// that turn text2 into text3.
+func composeSpans(a, b []patchSpan) ([]patchSpan, error) {
+	var ret []patchSpan
+	ca := &spanCursor{spans: a}
+	cb := &spanCursor{spans: b}
+	for !ca.done() || !cb.done() {
+		// Text deleted by a is not in text2, and text inserted by b is
+		// not in text2; both pass through.
+		if ca.edit() == Delete {
+			ret = appendPatchSpan(ret, ca.take(ca.left()))
+			continue
+		}
+		if cb.edit() == Insert {
+			ret = appendPatchSpan(ret, cb.take(cb.left()))
+			continue
+		}
+		if ca.done() || cb.done() {
+			return nil, fmt.Errorf("changes have different middle texts")
+		}
+
+		// Both a and b walk over text2.
+		na, nb := ca.left(), cb.left()
+		if na == spanRest && nb == spanRest {
+			ret = appendPatchSpan(ret, patchSpan{gap: spanRest})
+			break
+		}
+		n := na
+		if n == spanRest || (nb != spanRest && nb < n) {
+			n = nb
+		}
+		sa := ca.take(n)
+		sb := cb.take(n)
+		if !sa.isGap() && !sb.isGap() && sa.Text != sb.Text {
+			return nil, fmt.Errorf(
+				"changes have different middle texts: %q and %q",
+				sa.Text, sb.Text,
+			)
+		}
+
+		switch {
+		case !sb.isGap() && sb.Type == Delete:
+			if !sa.isGap() && sa.Type == Insert {
+				// Inserted by a, and then deleted by b.
+				continue
+			}
+			ret = appendPatchSpan(ret, sb)
+		case !sa.isGap():
+			// Inserted by a or unchanged, and kept by b.
+			ret = appendPatchSpan(ret, sa)
+		default:
+			// The text is only known by b, if at all.
+			ret = appendPatchSpan(ret, sb)
+		}
+	}
+	return orderEdits(ret), nil
+}
+
+func diffsToSpans(diffs []Diff) []patchSpan {
+	var spans []patchSpan
+	for _, d := range diffs {
+		spans = appendPatchSpan(spans, patchSpan{Diff: d})
+	}
+	return spans
+}
+
+func spansToDiffs(spans []patchSpan) []Diff {
+	var diffs []Diff
+	for _, s := range spans {
+		diffs = append(diffs, s.Diff)
+	}
+	return diffs
+}
+
+func invertSpans(spans []patchSpan) {
+	for i := range spans {
+		s := &spans[i]
+		if !s.isGap() {
+			s.Type = -s.Type
+		}
+	}
+}
+
+// DiffInvert inverts diffs that turn text1 into text2 into diffs that
+// turn text2 into text1.
+func DiffInvert(diffs []Diff) []Diff {
+	spans := diffsToSpans(diffs)
+	invertSpans(spans)
+	return spansToDiffs(orderEdits(spans))
+}
+
+// DiffCompose composes diffs a that turn text1 into text2 and diffs b that
+// turn text2 into text3 into diffs that turn text1 into text3. It returns
+// an error if text2 of a is not text1 of b.
+func DiffCompose(a, b []Diff) ([]Diff, error) {
+	if t1, t2 := DiffText2(a), DiffText1(b); t1 != t2 {
+		return nil, fmt.Errorf(
+			"diffs have different middle texts: %q and %q", t1, t2,
+		)
+	}
+	spans, err := composeSpans(diffsToSpans(a), diffsToSpans(b))
+	if err != nil {
+		return nil, err
+	}
+	return spansToDiffs(spans), nil
+}
+
+// PatchInvert inverts patches that turn text1 into text2 into patches that
+// turn text2 into text1. Context that neighboring patches share is merged.
+func PatchInvert(ps []Patch) ([]Patch, error) {
+	spans, err := patchesToSpans(ps)
+	if err != nil {
+		return nil, err
+	}
+	invertSpans(spans)
+	return spansToPatches(orderEdits(spans)), nil
+}
+
+// PatchCompose composes patches a that turn text1 into text2 and patches b
+// that turn text2 into text3 into patches that turn text1 into text3. The
+// text is not needed; the context of the patches fills in what is known
+// of it. It returns an error if the context of a and b disagree.
+func PatchCompose(a, b []Patch) ([]Patch, error) {
+	sa, err := patchesToSpans(a)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := patchesToSpans(b)
+	if err != nil {
+		return nil, err
+	}
+	spans, err := composeSpans(sa, sb)
+	if err != nil {
+		return nil, err
+	}
+	return spansToPatches(spans), nil
+}
@@ -0,0 +1,146 @@
+package diffmp
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestDiffInvert(t *testing.T) {
+	diffs := []Diff{
+		{Noop, "The "},
+		{Delete, "quick"},
+		{Insert, "slow"},
+		{Noop, " fox"},
+		{Insert, "!"},
+	}
+	assertDiffEqual(t, []Diff{
+		{Noop, "The "},
+		{Delete, "slow"},
+		{Insert, "quick"},
+		{Noop, " fox"},
+		{Delete, "!"},
+	}, DiffInvert(diffs))
+}
+
+func TestDiffCompose(t *testing.T) {
+	a := []Diff{
+		{Noop, "The "},
+		{Delete, "quick"},
+		{Insert, "slow"},
+		{Noop, " brown fox"},
+	}
+	b := []Diff{
+		{Noop, "The sl"},
+		{Delete, "ow"},
+		{Insert, "y"},
+		{Noop, " "},
+		{Delete, "brown "},
+		{Noop, "fox"},
+		{Insert, "."},
+	}
+	got, err := DiffCompose(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDiffEqual(t, []Diff{
+		{Noop, "The "},
+		{Delete, "quick"},
+		{Insert, "sly"},
+		{Noop, " "},
+		{Delete, "brown "},
+		{Noop, "fox"},
+		{Insert, "."},
+	}, got)
+
+	_, err = DiffCompose(a, a)
+	assert.NotNil(t, err)
+}
+
+func randEdit(r *rand.Rand, s string) string {
+	words := []string{"alpha", "beta", " ", "\n", "δέλτα", "日本", "x"}
+	rs := []rune(s)
+	for i := r.Intn(4); i >= 0; i-- {
+		p := r.Intn(len(rs) + 1)
+		n := min(len(rs)-p, r.Intn(8))
+		w := []rune(words[r.Intn(len(words))])
+		if r.Intn(3) == 0 {
+			w = nil
+		}
+		rs = append(rs[:p], append(w, rs[p+n:]...)...)
+	}
+	return string(rs)
+}
+
+func TestDiffComposeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	dmp := New()
+	for i := 0; i < 300; i++ {
+		text1 := randEdit(r, strings.Repeat("alpha beta δέλτα\n", 5))
+		text2 := randEdit(r, text1)
+		text3 := randEdit(r, text2)
+		a := dmp.DiffMain(text1, text2, false)
+		b := dmp.DiffMain(text2, text3, false)
+		c, err := DiffCompose(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, text1, DiffText1(c))
+		assert.Equal(t, text3, DiffText2(c))
+
+		inv := DiffInvert(c)
+		assert.Equal(t, text3, DiffText1(inv))
+		assert.Equal(t, text1, DiffText2(inv))
+	}
+}
+
+func TestPatchInvert(t *testing.T) {
+	dmp := New()
+	text1 := "The quick brown fox jumps over the lazy dog."
+	text2 := "That quick brown fox jumped over a lazy dog."
+	ps, err := PatchInvert(dmp.PatchMake(text1, text2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t,
+		"@@ -1,12 +1,11 @@\n Th\n-at\n+e\n  quick b\n"+
+			"@@ -21,17 +21,18 @@\n jump\n-ed\n+s\n  over \n-a\n+the\n  laz\n",
+		PatchToText(ps),
+	)
+}
+
+func TestPatchComposeRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	dmp := New()
+	var base []string
+	for i := 0; i < 40; i++ {
+		base = append(base, "line ", string(rune('a'+i%26)), " δέλτα\n")
+	}
+	for i := 0; i < 300; i++ {
+		text1 := randEdit(r, strings.Join(base, ""))
+		text2 := randEdit(r, text1)
+		if i%2 == 1 {
+			text2 = randEdit(r, text2) + strings.Repeat("long insert ", 8)
+		}
+		text3 := randEdit(r, text2)
+		a := dmp.PatchMake(text1, text2)
+		b := dmp.PatchMake(text2, text3)
+
+		c, err := PatchCompose(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, ValidatePatches(c))
+		got, _ := dmp.Apply(c, text1)
+		assert.Equal(t, text3, got)
+
+		inv, err := PatchInvert(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ = dmp.Apply(inv, text3)
+		assert.Equal(t, text1, got)
+	}
+}
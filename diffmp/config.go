@@ -10,6 +10,11 @@ type DMP struct {
 	// Number of seconds to map a diff before giving up (0 for infinity).
 	DiffTimeout time.Duration
 
+	// The maximum number of bisect steps to map a diff before giving up (0
+	// for infinity). Unlike DiffTimeout, the result does not depend on the
+	// speed of the machine.
+	DiffMaxSteps int
+
 	// Cost of an empty edit operation in terms of edit characters.
 	DiffEditCost int
 
@@ -27,7 +32,11 @@ type DMP struct {
 	// Chunk size for context length.
 	PatchMargin int
 
-	// The number of bits in an int.
+	// The maximum length of a pattern in a patch. Longer patches are split
+	// before they are applied, and the context of a patch does not grow
+	// beyond it. The default is 32, as in the other diff-match-patch
+	// libraries. 0 means no limit: Bitap matches patterns of any length,
+	// and patches are not split.
 	MatchMaxBits int
 
 	// At what point is no match declared (0.0 = perfection, 1.0 = very
@@ -0,0 +1,140 @@
+package diffmp
+
+// diffBisect finds the 'middle snake' of a diff, splits the problem in two
+// and returns the recursively constructed diff.
+// See Myers's 1986 paper: An O(ND) Difference Algorithm and Its Variations.
+func diffBisect(dmp *DMP, s1, s2 []int32, r *diffRun) []TokenDiff {
+	// Cache the text lengths to prevent multiple calls.
+	len1, len2 := len(s1), len(s2)
+
+	dmax := (len1 + len2 + 1) / 2
+	offset := dmax
+	vlen := 2 * dmax
+
+	v1 := make([]int, vlen)
+	v2 := make([]int, vlen)
+	for i := range v1 {
+		v1[i] = -1
+		v2[i] = -1
+	}
+	v1[offset+1] = 0
+	v2[offset+1] = 0
+
+	delta := len1 - len2
+	// If the total number of characters is odd, then the front path will
+	// collide with the reverse path.
+	front := delta%2 != 0
+	// Offsets for start and end of k loop.
+	// Prevents mapping of space beyond the grid.
+	k1start := 0
+	k1end := 0
+	k2start := 0
+	k2end := 0
+	for d := 0; d < dmax; d++ {
+		// Bail out if the time or step budget is used up.
+		if r.stop() {
+			break
+		}
+
+		// Walk the front path one step.
+		for k1 := -d + k1start; k1 <= d-k1end; k1 += 2 {
+			k1Offset := offset + k1
+			var x1 int
+
+			if k1 == -d || (k1 != d && v1[k1Offset-1] < v1[k1Offset+1]) {
+				x1 = v1[k1Offset+1]
+			} else {
+				x1 = v1[k1Offset-1] + 1
+			}
+
+			y1 := x1 - k1
+			for x1 < len1 && y1 < len2 {
+				if s1[x1] != s2[y1] {
+					break
+				}
+				x1++
+				y1++
+			}
+			v1[k1Offset] = x1
+			r.steps++
+			if x1 > len1 {
+				// Ran off the right of the graph.
+				k1end += 2
+			} else if y1 > len2 {
+				// Ran off the bottom of the graph.
+				k1start += 2
+			} else if front {
+				k2Offset := offset + delta - k1
+				if k2Offset >= 0 && k2Offset < vlen &&
+					v2[k2Offset] != -1 {
+					// Mirror x2 onto top-left coordinate system.
+					x2 := len1 - v2[k2Offset]
+					if x1 >= x2 {
+						// Overlap detected.
+						return diffBisectSplit(dmp,
+							s1, s2, x1, y1, r,
+						)
+					}
+				}
+			}
+		}
+		// Walk the reverse path one step.
+		for k2 := -d + k2start; k2 <= d-k2end; k2 += 2 {
+			k2Offset := offset + k2
+			var x2 int
+			if k2 == -d || (k2 != d && v2[k2Offset-1] < v2[k2Offset+1]) {
+				x2 = v2[k2Offset+1]
+			} else {
+				x2 = v2[k2Offset-1] + 1
+			}
+			var y2 = x2 - k2
+			for x2 < len1 && y2 < len2 {
+				if s1[len1-x2-1] != s2[len2-y2-1] {
+					break
+				}
+				x2++
+				y2++
+			}
+			v2[k2Offset] = x2
+			r.steps++
+			if x2 > len1 {
+				// Ran off the left of the graph.
+				k2end += 2
+			} else if y2 > len2 {
+				// Ran off the top of the graph.
+				k2start += 2
+			} else if !front {
+				k1Offset := offset + delta - k2
+				if k1Offset >= 0 && k1Offset < vlen &&
+					v1[k1Offset] != -1 {
+					x1 := v1[k1Offset]
+					y1 := offset + x1 - k1Offset
+					// Mirror x2 onto top-left coordinate system.
+					x2 = len1 - x2
+					if x1 >= x2 {
+						// Overlap detected.
+						return diffBisectSplit(dmp,
+							s1, s2, x1, y1, r,
+						)
+					}
+				}
+			}
+		}
+	}
+	// Diff took too long and hit the time or step budget, or
+	// number of diffs equals number of characters, no commonality at all.
+	return []TokenDiff{{Delete, s1}, {Insert, s2}}
+}
+
+func diffBisectSplit(dmp *DMP, s1, s2 []int32, x, y int,
+	r *diffRun) []TokenDiff {
+	s1a := s1[:x]
+	s2a := s2[:y]
+	s1b := s1[x:]
+	s2b := s2[y:]
+
+	// Compute both diffs serially.
+	diffs := diffMainIDs(dmp, s1a, s2a, false, r)
+	diffsb := diffMainIDs(dmp, s1b, s2b, false, r)
+	return append(diffs, diffsb...)
+}
@@ -0,0 +1,76 @@
+package diffmp
+
+import (
+	"context"
+	"time"
+)
+
+// diffRun tracks the limits of one diff computation: the wall-clock
+// deadline, the context, and the budget of bisect steps.
+type diffRun struct {
+	ctx      context.Context // nil for never cancelled
+	deadline time.Time
+	maxSteps int // 0 for unlimited
+	steps    int
+
+	cut bool // the search was cut short
+	err error
+}
+
+func newDiffRun(ctx context.Context, dmp *DMP) *diffRun {
+	return &diffRun{
+		ctx:      ctx,
+		deadline: deadline(dmp.DiffTimeout),
+		maxSteps: dmp.DiffMaxSteps,
+	}
+}
+
+// stop checks if the search has to stop before taking more steps. Once it
+// returns true, it keeps returning true.
+func (r *diffRun) stop() bool {
+	if r.cut {
+		return true
+	}
+	if r.maxSteps > 0 && r.steps >= r.maxSteps {
+		r.cut = true
+	} else if time.Now().After(r.deadline) {
+		r.cut = true
+	} else if r.ctx != nil {
+		select {
+		case <-r.ctx.Done():
+			r.cut = true
+			r.err = r.ctx.Err()
+		default:
+		}
+	}
+	return r.cut
+}
+
+// DiffResult is the result of DiffMainContext.
+type DiffResult struct {
+	Diffs []Diff
+
+	// Steps is the number of bisect steps taken, where a step extends
+	// one diagonal of the edit graph by one edit.
+	Steps int
+
+	// Cut is true when the search ran out of time or steps. The diffs are
+	// still correct, but might not be minimal.
+	Cut bool
+}
+
+// DiffMainContext finds the differences between two texts like DiffMain,
+// but stops when ctx is done, and returns ctx.Err() then. The search also
+// stops after DiffMaxSteps bisect steps, or when DiffTimeout passes; in
+// these cases, the result is marked as cut. Set DiffTimeout to 0 and use
+// DiffMaxSteps for results that do not depend on the machine's load.
+func (dmp *DMP) DiffMainContext(
+	ctx context.Context, s1, s2 string, checkLines bool,
+) (*DiffResult, error) {
+	r := newDiffRun(ctx, dmp)
+	diffs := diffMain(dmp, s1, s2, checkLines, r)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &DiffResult{Diffs: diffs, Steps: r.steps, Cut: r.cut}, nil
+}
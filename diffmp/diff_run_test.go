@@ -0,0 +1,65 @@
+package diffmp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestDiffMainContext(t *testing.T) {
+	a := strings.Repeat("`Twas brillig, and the slithy toves\n", 64)
+	b := strings.Repeat("I am the very model of a modern major general\n", 64)
+
+	dmp := New()
+	dmp.DiffTimeout = 0
+	ctx := context.Background()
+	full, err := dmp.DiffMainContext(ctx, a, b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, full.Cut)
+	assert.True(t, full.Steps > 0)
+	assert.Equal(t, a, DiffText1(full.Diffs))
+	assert.Equal(t, b, DiffText2(full.Diffs))
+
+	// A small step budget cuts the search short, but the diff is still
+	// valid, and the same every time.
+	dmp.DiffMaxSteps = 1000
+	cut, err := dmp.DiffMainContext(ctx, a, b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, cut.Cut)
+	assert.True(t, cut.Steps < full.Steps)
+	assert.Equal(t, a, DiffText1(cut.Diffs))
+	assert.Equal(t, b, DiffText2(cut.Diffs))
+
+	again, err := dmp.DiffMainContext(ctx, a, b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, cut, again)
+
+	// A budget that is not used up does not change the result.
+	dmp.DiffMaxSteps = full.Steps
+	res, err := dmp.DiffMainContext(ctx, a, b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, full, res)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	res, err = dmp.DiffMainContext(cancelled, a, b, false)
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, res)
+
+	// Equal texts need no search, and are never cut.
+	res, err = dmp.DiffMainContext(cancelled, a, a, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Diff{{Noop, a}}, res.Diffs)
+}
@@ -12,8 +12,15 @@ import (
 // operations required to transform text1 into text2.
 // E.g. =3\t-2\t+ing  -> Keep 3 chars, delete 2 chars, insert 'ing'.
 // Operations are tab-separated.  Inserted text is escaped using %xx
-// notation.
+// notation. Lengths are counted in runes.
 func DiffToDelta(diffs []Diff) string {
+	return DiffToDeltaUnit(diffs, UnitRunes)
+}
+
+// DiffToDeltaUnit works like DiffToDelta, but counts lengths in unit u.
+// Use UnitUTF16 to exchange deltas with the JavaScript and Java
+// libraries.
+func DiffToDeltaUnit(diffs []Diff, u Unit) string {
 	var buf bytes.Buffer
 	for _, d := range diffs {
 		switch d.Type {
@@ -26,12 +33,12 @@ func DiffToDelta(diffs []Diff) string {
 			break
 		case Delete:
 			buf.WriteString("-")
-			buf.WriteString(strconv.Itoa(utf8.RuneCountInString(d.Text)))
+			buf.WriteString(strconv.Itoa(unitLen(d.Text, u)))
 			buf.WriteString("\t")
 			break
 		case Noop:
 			buf.WriteString("=")
-			buf.WriteString(strconv.Itoa(utf8.RuneCountInString(d.Text)))
+			buf.WriteString(strconv.Itoa(unitLen(d.Text, u)))
 			buf.WriteString("\t")
 			break
 		}
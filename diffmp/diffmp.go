@@ -1,18 +1,17 @@
 package diffmp
 
 import (
-	"math"
 	"time"
 )
 
 // DiffMain finds the differences between two texts.
 func (dmp *DMP) DiffMain(s1, s2 string, checkLines bool) []Diff {
-	return diffMain(dmp, s1, s2, checkLines, deadline(dmp.DiffTimeout))
+	return diffMain(dmp, s1, s2, checkLines, newDiffRun(nil, dmp))
 }
 
 // DiffMainRunes finds the differences between two rune sequences.
 func (dmp *DMP) DiffMainRunes(s1, s2 []rune, checkLines bool) []Diff {
-	return diffMainRunes(dmp, s1, s2, checkLines, deadline(dmp.DiffTimeout))
+	return diffMainRunes(dmp, s1, s2, checkLines, newDiffRun(nil, dmp))
 }
 
 // DiffBisect finds the 'middle snake' of a diff, split the problem in two
@@ -20,7 +19,8 @@ func (dmp *DMP) DiffMainRunes(s1, s2 []rune, checkLines bool) []Diff {
 // See Myers 1986 paper: An O(ND) Difference Algorithm and Its Variations.
 func (dmp *DMP) DiffBisect(s1, s2 string, deadline time.Time) []Diff {
 	// Unused in this code, but retained for interface compatibility.
-	return diffBisect(dmp, []rune(s1), []rune(s2), deadline)
+	r := &diffRun{deadline: deadline, maxSteps: dmp.DiffMaxSteps}
+	return tokenDiffsToDiffs(diffBisect(dmp, []rune(s1), []rune(s2), r))
 }
 
 // DiffHalfMatch checks whether the two texts share a substring which is at
@@ -51,39 +51,31 @@ func (dmp *DMP) DiffCleanupEfficiency(diffs []Diff) []Diff {
 // MatchMain locates the best instance of 'pattern' in 'text' near 'loc'.
 // Returns -1 if no match found.
 func (dmp *DMP) MatchMain(s, pattern string, loc int) int {
-	// Check for null inputs not needed since null can't be passed in C#.
-
-	loc = int(math.Max(0, math.Min(float64(loc), float64(len(s)))))
-	if s == pattern {
-		// Shortcut (potentially not guaranteed by the algorithm)
-		return 0
-	} else if len(s) == 0 {
-		// Nothing to match.
-		return -1
-	} else if loc+len(pattern) <= len(s) &&
-		s[loc:loc+len(pattern)] == pattern {
-		// Perfect match at the perfect spot!  (Includes case of null pattern)
-		return loc
-	}
-	// Do a fuzzy compare.
-	return dmp.MatchBitap(s, pattern, loc)
+	ret, _ := matchMain(dmp, s, pattern, loc)
+	return ret
 }
 
 // MatchBitap locates the best instance of 'pattern' in 'text' near 'loc'
 // using the Bitap algorithm.  Returns -1 if no match found.
 func (dmp *DMP) MatchBitap(text, pattern string, loc int) int {
-	return matchBitap(dmp, text, pattern, loc)
+	ret, _ := matchBitap(dmp, text, pattern, loc)
+	return ret
 }
 
 //  PATCH FUNCTIONS
 
 // PatchAddContext increases the context until it is unique,
-// but doesn't let the pattern expand beyond MatchMaxBits.
+// but doesn't let the pattern expand beyond MatchMaxBits, or 256 bytes when
+// MatchMaxBits is 0.
 func (dmp *DMP) PatchAddContext(p Patch, s string) Patch {
 	return patchAddContext(dmp, p, s)
 }
 
-// PatchMake makes a patch.
+// PatchMake makes a patch. The arguments can be two texts, a text and
+// diffs, diffs only, or the deprecated text, text and diffs. A wrong call
+// panics or returns no patches, and the input is not checked;
+// PatchFromTexts, PatchFromDiffs and PatchFromTextAndDiffs return errors
+// instead.
 func (dmp *DMP) PatchMake(opt ...interface{}) []Patch {
 	switch len(opt) {
 	case 1:
@@ -95,12 +87,7 @@ func (dmp *DMP) PatchMake(opt ...interface{}) []Patch {
 		text1 := opt[0].(string)
 		switch t := opt[1].(type) {
 		case string:
-			diffs := dmp.DiffMain(text1, t, true)
-			if len(diffs) > 2 {
-				diffs = DiffCleanupSemantic(diffs)
-				diffs = dmp.DiffCleanupEfficiency(diffs)
-			}
-			return dmp.PatchMake(text1, diffs)
+			return patchMake2(dmp, text1, dmp.patchDiffs(text1, t))
 		case []Diff:
 			return patchMake2(dmp, text1, t)
 		}
@@ -115,112 +102,24 @@ func (dmp *DMP) PatchMake(opt ...interface{}) []Patch {
 // as well as an array of true/false values indicating which patches were
 // applied.
 func (dmp *DMP) Apply(ps []Patch, s string) (string, []bool) {
-	if len(ps) == 0 {
-		return s, []bool{}
+	s, reports := patchApply(dmp, ps, s, nil)
+	results := make([]bool, len(reports))
+	for i, r := range reports {
+		results[i] = r.Applied
 	}
-
-	// Deep copy the patches so that no changes are made to originals.
-	ps = PatchDeepCopy(ps)
-
-	nullPadding := patchAddPadding(ps, dmp.PatchMargin)
-	s = nullPadding + s + nullPadding
-	ps = patchSplitMax(ps, dmp.MatchMaxBits, dmp.PatchMargin)
-
-	x := 0
-	// delta keeps track of the offset between the expected and actual
-	// location of the previous patch.  If there are patches expected at
-	// positions 10 and 20, but the first patch was found at 12, delta is 2
-	// and the second patch has an effective expected position of 22.
-	delta := 0
-	results := make([]bool, len(ps))
-	for _, p := range ps {
-		expectedLoc := p.start2 + delta
-		s1 := DiffText1(p.diffs)
-		var startLoc int
-		endLoc := -1
-		if len(s1) > dmp.MatchMaxBits {
-			// PatchSplitMax will only provide an oversized pattern
-			// in the case of a monster delete.
-			startLoc = dmp.MatchMain(
-				s, s1[:dmp.MatchMaxBits], expectedLoc,
-			)
-			if startLoc != -1 {
-				endLoc = dmp.MatchMain(
-					s, s1[len(s1)-dmp.MatchMaxBits:],
-					expectedLoc+len(s1)-dmp.MatchMaxBits,
-				)
-				if endLoc == -1 || startLoc >= endLoc {
-					// Can't find valid trailing context.  Drop this patch.
-					startLoc = -1
-				}
-			}
-		} else {
-			startLoc = dmp.MatchMain(s, s1, expectedLoc)
-		}
-		if startLoc == -1 {
-			// No match found.  :(
-			results[x] = false
-			// Subtract the delta for this failed patch from subsequent
-			// patches.
-			delta -= p.length2 - p.length1
-		} else {
-			// Found a match.  :)
-			results[x] = true
-			delta = startLoc - expectedLoc
-			var s2 string
-			if endLoc == -1 {
-				s2 = s[startLoc:min(startLoc+len(s1), len(s))]
-			} else {
-				s2 = s[startLoc:min(endLoc+dmp.MatchMaxBits, len(s))]
-			}
-			if s1 == s2 {
-				// Perfect match, just shove the Replacement text in.
-				s = s[:startLoc] + DiffText2(p.diffs) +
-					s[startLoc+len(s1):]
-			} else {
-				// Imperfect match.  Run a diff to get a framework of
-				// equivalent indices.
-				diffs := dmp.DiffMain(s1, s2, false)
-				if len(s1) > dmp.MatchMaxBits &&
-					float64(DiffLevenshtein(diffs))/float64(len(s1)) >
-						dmp.PatchDeleteThreshold {
-					// The end points match, but the content is unacceptably
-					// bad.
-					results[x] = false
-				} else {
-					diffs = DiffCleanupSemanticLossless(diffs)
-					index1 := 0
-					for _, d := range p.diffs {
-						if d.Type != Noop {
-							index2 := DiffXIndex(diffs, index1)
-							if d.Type == Insert {
-								// Insertion
-								s = s[:startLoc+index2] +
-									d.Text + s[startLoc+index2:]
-							} else if d.Type == Delete {
-								// Deletion
-								startIndex := startLoc + index2
-								s = s[:startIndex] +
-									s[startIndex+DiffXIndex(
-										diffs,
-										index1+len(d.Text),
-									)-index2:]
-							}
-						}
-						if d.Type != Delete {
-							index1 += len(d.Text)
-						}
-					}
-				}
-			}
-		}
-		x++
-	}
-	// Strip the padding off.
-	s = s[len(nullPadding) : len(nullPadding)+(len(s)-2*len(nullPadding))]
 	return s, results
 }
 
+// ApplyWithReport works like Apply, but returns a report for each patch,
+// with where it is expected and found, how well it matches, and why it
+// is not applied. Patches that are split by PatchSplitMax have a report
+// for each part.
+func (dmp *DMP) ApplyWithReport(ps []Patch, s string, opts *ApplyOptions) (
+	string, []*PatchReport,
+) {
+	return patchApply(dmp, ps, s, opts)
+}
+
 // PatchAddPadding adds some padding on text start and end so that edges can
 // match something.  Intended to be called only from within patch_apply.
 func (dmp *DMP) PatchAddPadding(ps []Patch) string {
@@ -228,7 +127,7 @@ func (dmp *DMP) PatchAddPadding(ps []Patch) string {
 }
 
 // PatchSplitMax looks through the patches and breaks up any which are longer
-// than the maximum limit of the match algorithm.
+// than MatchMaxBits. Patches are not changed when MatchMaxBits is 0.
 // Intended to be called only from within patch_apply.
 func (dmp *DMP) PatchSplitMax(ps []Patch) []Patch {
 	return patchSplitMax(ps, dmp.MatchMaxBits, dmp.PatchMargin)
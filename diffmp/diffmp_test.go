@@ -74,8 +74,11 @@ func TestDiffHalfmatchTest(t *testing.T) {
 func TestDiffBisectSplit(t *testing.T) {
 	// As originally written, this can produce invalid utf8 strings.
 	dmp := New()
-	diffs := diffBisectSplit(dmp, []rune("STUV\x05WX\x05YZ\x05["),
-		[]rune("WĺĻļ\x05YZ\x05ĽľĿŀZ"), 7, 6, time.Now().Add(time.Hour))
+	r := &diffRun{deadline: time.Now().Add(time.Hour)}
+	diffs := tokenDiffsToDiffs(diffBisectSplit(dmp,
+		[]rune("STUV\x05WX\x05YZ\x05["),
+		[]rune("WĺĻļ\x05YZ\x05ĽľĿŀZ"), 7, 6, r,
+	))
 	for _, d := range diffs {
 		assert.True(t, utf8.ValidString(d.Text))
 	}
@@ -459,6 +462,16 @@ func TestDiffCleanupSemantic(t *testing.T) {
 		{Noop, "xxx"},
 		{Delete, "abc"}}, diffs)
 
+	// Reverse overlap elimination with edits of different lengths.
+	diffs = []Diff{
+		{Delete, "xxxab"},
+		{Insert, "dxxx"}}
+	diffs = DiffCleanupSemantic(diffs)
+	assertDiffEqual(t, []Diff{
+		{Insert, "d"},
+		{Noop, "xxx"},
+		{Delete, "ab"}}, diffs)
+
 	// Two overlap eliminations.
 	diffs = []Diff{
 		{Delete, "abcd1212"},
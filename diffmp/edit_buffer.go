@@ -0,0 +1,150 @@
+package diffmp
+
+import (
+	"unicode/utf8"
+)
+
+// editBuffer is a gap buffer that patches are applied on. Edits near the
+// gap are cheap, and patches are mostly applied in order, so the gap
+// moves across the text about once. The text is only materialized at the
+// end.
+type editBuffer struct {
+	buf      []byte
+	gapStart int
+	gapEnd   int
+}
+
+func newEditBuffer(s string) *editBuffer {
+	buf := make([]byte, len(s)+len(s)/8+64)
+	gap := len(buf) - len(s)
+	copy(buf[gap:], s)
+	return &editBuffer{buf: buf, gapStart: 0, gapEnd: gap}
+}
+
+// Len returns the length of the text in bytes.
+func (b *editBuffer) Len() int { return len(b.buf) - (b.gapEnd - b.gapStart) }
+
+// moveGap moves the gap to byte offset pos of the text.
+func (b *editBuffer) moveGap(pos int) {
+	if pos < b.gapStart {
+		n := b.gapStart - pos
+		copy(b.buf[b.gapEnd-n:b.gapEnd], b.buf[pos:b.gapStart])
+		b.gapStart -= n
+		b.gapEnd -= n
+	} else if pos > b.gapStart {
+		n := pos - b.gapStart
+		copy(b.buf[b.gapStart:b.gapStart+n], b.buf[b.gapEnd:b.gapEnd+n])
+		b.gapStart += n
+		b.gapEnd += n
+	}
+}
+
+// grow makes the gap at least n bytes long.
+func (b *editBuffer) grow(n int) {
+	if b.gapEnd-b.gapStart >= n {
+		return
+	}
+	size := len(b.buf) + n + len(b.buf)/2
+	buf := make([]byte, size)
+	copy(buf, b.buf[:b.gapStart])
+	tail := len(b.buf) - b.gapEnd
+	copy(buf[size-tail:], b.buf[b.gapEnd:])
+	b.buf = buf
+	b.gapEnd = size - tail
+}
+
+// slice returns the text from byte offset start to end.
+func (b *editBuffer) slice(start, end int) string {
+	gap := b.gapEnd - b.gapStart
+	switch {
+	case end <= b.gapStart:
+		return string(b.buf[start:end])
+	case start >= b.gapStart:
+		return string(b.buf[start+gap : end+gap])
+	}
+	ret := make([]byte, 0, end-start)
+	ret = append(ret, b.buf[start:b.gapStart]...)
+	ret = append(ret, b.buf[b.gapEnd:end+gap]...)
+	return string(ret)
+}
+
+// byteAt returns the byte at offset i of the text.
+func (b *editBuffer) byteAt(i int) byte {
+	if i >= b.gapStart {
+		i += b.gapEnd - b.gapStart
+	}
+	return b.buf[i]
+}
+
+// hasAt checks if the text has s at byte offset i.
+func (b *editBuffer) hasAt(i int, s string) bool {
+	if i < 0 || i+len(s) > b.Len() {
+		return false
+	}
+	for j := 0; j < len(s); j++ {
+		if b.byteAt(i+j) != s[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// replace replaces the text from byte offset start to end with s.
+func (b *editBuffer) replace(start, end int, s string) {
+	b.moveGap(end)
+	b.gapStart = start
+	b.grow(len(s))
+	copy(b.buf[b.gapStart:], s)
+	b.gapStart += len(s)
+}
+
+func (b *editBuffer) String() string { return b.slice(0, b.Len()) }
+
+// matchRadius returns how far in bytes from the expected location a match
+// of pattern can be, or -1 if there is no limit. Bitap gives up on
+// matches more than MatchThreshold * MatchDistance runes away.
+func matchRadius(dmp *DMP, pattern string) int {
+	const maxRadius = 1 << 24
+	dist := 0
+	if dmp.MatchDistance > 0 {
+		d := dmp.MatchThreshold * float64(dmp.MatchDistance)
+		if !(d < maxRadius) {
+			return -1
+		}
+		dist = int(d)
+	} else if !(dmp.MatchThreshold < 1) {
+		return -1
+	}
+	// A rune is at most utf8.UTFMax bytes.
+	return utf8.UTFMax * (dist + 2 + 2*len(pattern))
+}
+
+// match works like matchMain on the text of the buffer, but only reads
+// the part of the text that a match can be in.
+func (b *editBuffer) match(dmp *DMP, pattern string, loc int) (
+	int, float64,
+) {
+	n := b.Len()
+	loc = max(0, min(loc, n))
+	r := matchRadius(dmp, pattern)
+	if r < 0 || (loc-r <= 0 && loc+r >= n) {
+		return matchMain(dmp, b.String(), pattern, loc)
+	}
+	if b.hasAt(loc, pattern) {
+		return loc, 0
+	}
+
+	start := max(0, loc-r)
+	for start < loc && !utf8.RuneStart(b.byteAt(start)) {
+		start++
+	}
+	end := min(n, loc+r)
+	for end < n && !utf8.RuneStart(b.byteAt(end)) {
+		end++
+	}
+	ret, score := matchBitap(dmp, b.slice(start, end), pattern, loc-start)
+	if ret < 0 {
+		return -1, score
+	}
+	return start + ret, score
+}
@@ -0,0 +1,95 @@
+package diffmp
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestEditBuffer(t *testing.T) {
+	b := newEditBuffer("hello world")
+	b.replace(0, 5, "goodbye")
+	assert.Equal(t, "goodbye world", b.String())
+	b.replace(13, 13, "!")
+	assert.Equal(t, "goodbye world!", b.String())
+	b.replace(7, 8, strings.Repeat("-", 100))
+	assert.Equal(t, 113, b.Len())
+	assert.Equal(t, "goodbye--", b.slice(0, 9))
+	assert.Equal(t, "--world!", b.slice(105, 113))
+	b.replace(0, 107, "")
+	assert.Equal(t, "world!", b.String())
+	assert.True(t, b.hasAt(1, "orld"))
+	assert.False(t, b.hasAt(3, "ld!?"))
+}
+
+func TestEditBufferMatch(t *testing.T) {
+	dmp := New()
+	text := strings.Repeat("abcdefghij", 1000) + "xyz" +
+		strings.Repeat("abcdefghij", 1000)
+	b := newEditBuffer(text)
+	for _, test := range []struct {
+		pattern string
+		loc     int
+	}{
+		{"xyz", 10000},
+		{"xyz", 10050},
+		{"xyz", 9000},
+		{"jxyza", 10010},
+		{"hixyzab", 9990},
+		{"cdefg", 5003},
+		{"qqqq", 100},
+	} {
+		want, _ := matchMain(dmp, text, test.pattern, test.loc)
+		got, _ := b.match(dmp, test.pattern, test.loc)
+		assert.Equal(t, want, got, test.pattern)
+	}
+}
+
+func TestApplyLarge(t *testing.T) {
+	dmp := New()
+	diffs := largeApplyDiffs(200)
+	text1 := DiffText1(diffs)
+	patches := dmp.PatchMake(text1, diffs)
+	got, applied := dmp.Apply(patches, text1)
+	assert.Equal(t, DiffText2(diffs), got)
+	for _, ok := range applied {
+		assert.True(t, ok)
+	}
+}
+
+// largeApplyDiffs returns the diffs of n edits spread over a document of
+// about 500n bytes.
+func largeApplyDiffs(n int) []Diff {
+	r := rand.New(rand.NewSource(1))
+	words := []string{
+		"alpha ", "beta ", "gamma\n", "δέλτα ",
+		"日本 ", "the ", "quick ",
+	}
+	var diffs []Diff
+	for i := 0; i < n; i++ {
+		var text []string
+		for j := 0; j < 80; j++ {
+			text = append(text, words[r.Intn(len(words))])
+		}
+		diffs = append(diffs, Diff{Noop, strings.Join(text, "")})
+		if r.Intn(2) == 0 {
+			diffs = append(diffs, Diff{Delete, words[r.Intn(len(words))]})
+		}
+		diffs = append(diffs, Diff{Insert, "edited "})
+	}
+	return append(diffs, Diff{Noop, "."})
+}
+
+func BenchmarkApplyLarge(b *testing.B) {
+	dmp := New()
+	diffs := largeApplyDiffs(1000)
+	text1 := DiffText1(diffs)
+	patches := dmp.PatchMake(text1, diffs)
+	b.SetBytes(int64(len(text1)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dmp.Apply(patches, text1)
+	}
+}
@@ -10,10 +10,16 @@ import (
 
 // FromDelta takes the original s, which is an encoded string that
 // describes the operations required to transform text1 into text2,
-// and returns the full diff.
+// and returns the full diff. Lengths are counted in runes.
 func FromDelta(s, delta string) ([]Diff, error) {
+	return FromDeltaUnit(s, delta, UnitRunes)
+}
+
+// FromDeltaUnit works like FromDelta, but counts lengths in unit u.
+func FromDeltaUnit(s, delta string, u Unit) ([]Diff, error) {
 	diffs := []Diff{}
-	i := 0 // Cursor in text1
+	i := 0 // Cursor in text1, in bytes
+	units := 0
 	toks := strings.Split(delta, "\t")
 
 	for _, tok := range toks {
@@ -35,7 +41,7 @@ func FromDelta(s, delta string) ([]Diff, error) {
 			if err != nil {
 				return nil, err
 			}
-			if !utf8.ValidString(param) {
+			if u != unitRaw && !utf8.ValidString(param) {
 				return nil, fmt.Errorf("invalid UTF-8 token: %q", param)
 			}
 			diffs = append(diffs, Diff{Insert, param})
@@ -49,13 +55,17 @@ func FromDelta(s, delta string) ([]Diff, error) {
 				)
 			}
 
-			// remember that string slicing is by byte - we want by rune here.
-			runes := []rune(s)
-			if i+int(n) > len(runes) {
+			size, err := unitOffset(s[i:], int(n), u)
+			if err == errOutOfBound {
 				return diffs, fmt.Errorf("Index out of bound")
+			} else if err != nil {
+				return diffs, fmt.Errorf(
+					"Delta splits a character at %d", units+int(n),
+				)
 			}
-			text := string(runes[i : i+int(n)])
-			i += int(n)
+			text := s[i : i+size]
+			i += size
+			units += int(n)
 
 			if op == '=' {
 				diffs = append(diffs, Diff{Noop, text})
@@ -71,10 +81,10 @@ func FromDelta(s, delta string) ([]Diff, error) {
 		}
 	}
 
-	if i != len([]rune(s)) {
+	if i != len(s) {
 		return diffs, fmt.Errorf(
 			"Delta length (%d) smaller than source text length (%d)",
-			i, len(s),
+			units, unitLen(s, u),
 		)
 	}
 	return diffs, nil
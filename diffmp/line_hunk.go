@@ -0,0 +1,152 @@
+package diffmp
+
+// LineHunk is a hunk of line changes, as in a unified diff.
+type LineHunk struct {
+	// Start lines and line counts, as in the "@@ -1,3 +1,4 @@" header.
+	// Start lines are 1-based. When a count is 0, the start line is the
+	// line before the empty range.
+	OldStart, OldLines int
+	NewStart, NewLines int
+
+	// Lines are the lines of the hunk, each starting with ' ', '-' or '+'
+	// and ending with its line ending. The last line of a text that does
+	// not end with "\n" has no line ending.
+	Lines []string
+}
+
+// lineChange is a run of changed lines: lines [i1, i2) of text1 are
+// replaced with lines [j1, j2) of text2.
+type lineChange struct {
+	i1, i2, j1, j2 int
+}
+
+// keptLines returns the pairs of lines of text1 and text2 that the diffs
+// keep unchanged, in order.
+func keptLines(diffs []Diff, lines1, lines2 []string) [][2]int {
+	starts2 := make(map[int]int)
+	off := 0
+	for j, line := range lines2 {
+		starts2[off] = j
+		off += len(line)
+	}
+
+	m := NewPosMap(diffs)
+	var ret [][2]int
+	off = 0
+	for i, line := range lines1 {
+		a, b := off, off+len(line)
+		off = b
+
+		// The line must be all unchanged text, with nothing inserted into
+		// it, and must be a whole line of text2.
+		if m.keptBefore(0, b)-m.keptBefore(0, a) != len(line) {
+			continue
+		}
+		a2 := m.Map(a, BiasRight)
+		if m.Map(b, BiasLeft)-a2 != len(line) {
+			continue
+		}
+		j, ok := starts2[a2]
+		if !ok || len(lines2[j]) != len(line) {
+			continue
+		}
+		ret = append(ret, [2]int{i, j})
+	}
+	return ret
+}
+
+// lineChanges returns the runs of changed lines between the kept lines.
+// Equal lines at the ends of a run, which a diff of characters can leave
+// there when it edits across line endings, are taken out of the run.
+func lineChanges(kept [][2]int, lines1, lines2 []string) []*lineChange {
+	var ret []*lineChange
+	i, j := 0, 0
+	end := [2]int{len(lines1), len(lines2)}
+	for _, k := range append(kept, end) {
+		c := &lineChange{i, k[0], j, k[1]}
+		for c.i1 < c.i2 && c.j1 < c.j2 && lines1[c.i1] == lines2[c.j1] {
+			c.i1++
+			c.j1++
+		}
+		for c.i1 < c.i2 && c.j1 < c.j2 &&
+			lines1[c.i2-1] == lines2[c.j2-1] {
+			c.i2--
+			c.j2--
+		}
+		if c.i2 > c.i1 || c.j2 > c.j1 {
+			ret = append(ret, c)
+		}
+		i, j = k[0]+1, k[1]+1
+	}
+	return ret
+}
+
+// hunkStart returns the 1-based start line of a hunk range that starts at
+// 0-based line i and has n lines.
+func hunkStart(i, n int) int {
+	if n == 0 {
+		return i
+	}
+	return i + 1
+}
+
+func appendHunkLines(lines []string, prefix string, src []string) []string {
+	for _, line := range src {
+		lines = append(lines, prefix+line)
+	}
+	return lines
+}
+
+func makeLineHunk(
+	group []*lineChange, lines1, lines2 []string, context int,
+) *LineHunk {
+	first, last := group[0], group[len(group)-1]
+	start1 := max(0, first.i1-context)
+	end1 := min(len(lines1), last.i2+context)
+	start2 := first.j1 - (first.i1 - start1)
+	end2 := last.j2 + (end1 - last.i2)
+
+	h := &LineHunk{
+		OldStart: hunkStart(start1, end1-start1),
+		OldLines: end1 - start1,
+		NewStart: hunkStart(start2, end2-start2),
+		NewLines: end2 - start2,
+	}
+	pos := start1
+	for _, c := range group {
+		h.Lines = appendHunkLines(h.Lines, " ", lines1[pos:c.i1])
+		h.Lines = appendHunkLines(h.Lines, "-", lines1[c.i1:c.i2])
+		h.Lines = appendHunkLines(h.Lines, "+", lines2[c.j1:c.j2])
+		pos = c.i2
+	}
+	h.Lines = appendHunkLines(h.Lines, " ", lines1[pos:end1])
+	return h
+}
+
+// DiffLineHunks converts diffs into hunks of whole lines, with context
+// lines of unchanged text around the changes. The diffs may be of
+// characters or of lines. A line is unchanged when the diffs keep all of
+// it as a whole line; any other line with an edit in it is a changed
+// line.
+func DiffLineHunks(diffs []Diff, context int) []*LineHunk {
+	context = max(0, context)
+	lines1 := splitLines(DiffText1(diffs))
+	lines2 := splitLines(DiffText2(diffs))
+	kept := keptLines(diffs, lines1, lines2)
+	changes := lineChanges(kept, lines1, lines2)
+
+	var ret []*LineHunk
+	var group []*lineChange
+	for _, c := range changes {
+		n := len(group)
+		if n > 0 && c.i1-group[n-1].i2 > 2*context {
+			ret = append(ret, makeLineHunk(group, lines1, lines2, context))
+			group = nil
+		}
+		group = append(group, c)
+	}
+	if len(group) > 0 {
+		ret = append(ret, makeLineHunk(group, lines1, lines2, context))
+	}
+	return ret
+}
@@ -1,6 +1,8 @@
 package diffmp
 
-// MatchAlphabet initialises the alphabet for the Bitap algorithm.
+// MatchAlphabet initialises the alphabet for the Bitap algorithm on the
+// bytes of a pattern of at most 64 bytes. The matcher works on runes and
+// uses MatchAlphabetRunes instead.
 func MatchAlphabet(pattern string) map[byte]int {
 	s := map[byte]int{}
 	bs := []byte(pattern)
@@ -19,3 +21,30 @@ func MatchAlphabet(pattern string) map[byte]int {
 	}
 	return s
 }
+
+// MatchAlphabetRunes initialises the alphabet for the Bitap algorithm. It
+// maps each rune in the pattern to a bit vector, where the bit at
+// len(pattern)-i-1 is set if the rune is at index i of the pattern. Bit n
+// is bit n%64 of word n/64, so patterns of any length are supported.
+func MatchAlphabetRunes(pattern string) map[rune][]uint64 {
+	m := matchAlphabet([]rune(pattern))
+	ret := make(map[rune][]uint64, len(m))
+	for r, v := range m {
+		ret[r] = []uint64(v)
+	}
+	return ret
+}
+
+func matchAlphabet(pattern []rune) map[rune]bitVector {
+	s := make(map[rune]bitVector)
+	n := bitVectorWords(len(pattern))
+	for i, r := range pattern {
+		v, ok := s[r]
+		if !ok {
+			v = make(bitVector, n)
+			s[r] = v
+		}
+		v.set(len(pattern) - i - 1)
+	}
+	return s
+}
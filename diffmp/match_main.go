@@ -0,0 +1,28 @@
+package diffmp
+
+import (
+	"unicode/utf8"
+)
+
+// matchMain works like MatchMain, and also returns the score of the
+// match: 0 for an exact match at loc, and the Bitap score otherwise.
+func matchMain(dmp *DMP, s, pattern string, loc int) (int, float64) {
+	// Check for null inputs not needed since null can't be passed in C#.
+
+	loc = max(0, min(loc, len(s)))
+	if s == pattern {
+		// Shortcut (potentially not guaranteed by the algorithm)
+		n := max(1, utf8.RuneCountInString(pattern))
+		dist := utf8.RuneCountInString(s[:loc])
+		return 0, matchBitapScore(dmp, 0, 0, dist, n)
+	} else if len(s) == 0 {
+		// Nothing to match.
+		return -1, 1
+	} else if loc+len(pattern) <= len(s) &&
+		s[loc:loc+len(pattern)] == pattern {
+		// Perfect match at the perfect spot!  (Includes case of null pattern)
+		return loc, 0
+	}
+	// Do a fuzzy compare.
+	return matchBitap(dmp, s, pattern, loc)
+}
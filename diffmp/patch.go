@@ -20,23 +20,22 @@ type Patch struct {
 // Header: @@ -382,8 +481,9 @@
 // Indicies are printed as 1-based, not 0-based.
 func (p *Patch) String() string {
-	var coords1, coords2 string
-
-	if p.length1 == 0 {
-		coords1 = strconv.Itoa(p.start1) + ",0"
-	} else if p.length1 == 1 {
-		coords1 = strconv.Itoa(p.start1 + 1)
-	} else {
-		coords1 = strconv.Itoa(p.start1+1) + "," + strconv.Itoa(p.length1)
-	}
+	return p.format(p.start1, p.length1, p.start2, p.length2)
+}
 
-	if p.length2 == 0 {
-		coords2 = strconv.Itoa(p.start2) + ",0"
-	} else if p.length2 == 1 {
-		coords2 = strconv.Itoa(p.start2 + 1)
-	} else {
-		coords2 = strconv.Itoa(p.start2+1) + "," + strconv.Itoa(p.length2)
+func formatCoords(start, length int) string {
+	if length == 0 {
+		return strconv.Itoa(start) + ",0"
+	} else if length == 1 {
+		return strconv.Itoa(start + 1)
 	}
+	return strconv.Itoa(start+1) + "," + strconv.Itoa(length)
+}
+
+// format formats the patch with the given header coordinates.
+func (p *Patch) format(start1, length1, start2, length2 int) string {
+	coords1 := formatCoords(start1, length1)
+	coords2 := formatCoords(start2, length2)
 
 	var text bytes.Buffer
 	text.WriteString("@@ -" + coords1 + " +" + coords2 + " @@\n")
@@ -2,12 +2,23 @@ package diffmp
 
 import (
 	"strings"
+	"unicode/utf8"
 )
 
+// patchMaxContext limits how long the pattern of a patch grows to be unique
+// when MatchMaxBits is 0. Without a limit, a change in highly repetitive
+// text would take the whole text as context; the fuzzy match on apply
+// finds the right copy from the expected location anyway.
+const patchMaxContext = 256
+
 func patchAddContext(dmp *DMP, p Patch, s string) Patch {
 	if s == "" {
 		return p
 	}
+	maxPattern := dmp.MatchMaxBits
+	if maxPattern <= 0 {
+		maxPattern = patchMaxContext
+	}
 
 	pattern := s[p.start2 : p.start2+p.length1]
 	padding := 0
@@ -15,7 +26,7 @@ func patchAddContext(dmp *DMP, p Patch, s string) Patch {
 	// Look for the first and last matches of pattern in text.  If two
 	// different matches are found, increase the pattern length.
 	for strings.Index(s, pattern) != strings.LastIndex(s, pattern) &&
-		len(pattern) < dmp.MatchMaxBits-2*dmp.PatchMargin {
+		len(pattern) < maxPattern-2*dmp.PatchMargin {
 		padding += dmp.PatchMargin
 		maxStart := max(0, p.start2-padding)
 		minEnd := min(len(s), p.start2+p.length1+padding)
@@ -24,8 +35,18 @@ func patchAddContext(dmp *DMP, p Patch, s string) Patch {
 	// Add one chunk for good luck.
 	padding += dmp.PatchMargin
 
-	prefix := s[max(0, p.start2-padding):p.start2]
-	suffix := s[p.start2+p.length1 : min(len(s), p.start2+p.length1+padding)]
+	// Do not split characters at the ends of the context; grow it to
+	// whole characters so that it does not get shorter than the margin.
+	start := max(0, p.start2-padding)
+	for start > 0 && !utf8.RuneStart(s[start]) {
+		start--
+	}
+	end := min(len(s), p.start2+p.length1+padding)
+	for end < len(s) && !utf8.RuneStart(s[end]) {
+		end++
+	}
+	prefix := s[start:p.start2]
+	suffix := s[p.start2+p.length1 : end]
 
 	if len(prefix) != 0 {
 		p.diffs = diffPrepend(diffNoop(prefix), p.diffs)
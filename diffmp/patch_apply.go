@@ -0,0 +1,176 @@
+package diffmp
+
+import (
+	"unicode/utf8"
+)
+
+// patchLongPattern is the length in runes above which a patch is checked
+// against PatchDeleteThreshold when it does not match exactly. It is the
+// pattern width that the matcher used to be limited to.
+const patchLongPattern = 32
+
+// patchTail returns the last patchLongPattern bytes of s, starting at a
+// rune boundary.
+func patchTail(s string) string {
+	i := len(s) - patchLongPattern
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return s[i:]
+}
+
+// patchMatch locates the text s1 of a patch in b. It returns the start,
+// the end of the matched text, and the score of the match at the start.
+// The end is -1 if the text at the start has the same length as s1.
+func patchMatch(dmp *DMP, b *editBuffer, s1 string, expectedLoc int) (
+	int, int, float64, ApplyFailure,
+) {
+	maxBits := dmp.MatchMaxBits
+	var startLoc int
+	var score float64
+	endLoc := -1
+	if maxBits > 0 && len(s1) > maxBits {
+		// PatchSplitMax will only provide an oversized pattern
+		// in the case of a monster delete.
+		startLoc, score = b.match(dmp, s1[:maxBits], expectedLoc)
+		if startLoc == -1 {
+			return -1, -1, score, ApplyNoMatch
+		}
+		endLoc, _ = b.match(
+			dmp, s1[len(s1)-maxBits:], expectedLoc+len(s1)-maxBits,
+		)
+		if endLoc == -1 || startLoc >= endLoc {
+			// Can't find valid trailing context.  Drop this patch.
+			return -1, -1, score, ApplyNoTrailingContext
+		}
+		return startLoc, endLoc + maxBits, score, ApplyOK
+	}
+
+	// Without a limit, Bitap matches the whole pattern.
+	startLoc, score = b.match(dmp, s1, expectedLoc)
+	if startLoc == -1 {
+		return -1, -1, score, ApplyNoMatch
+	}
+	if maxBits <= 0 && len(s1) > patchLongPattern && !b.hasAt(startLoc, s1) {
+		// The text might be longer or shorter than the pattern at the
+		// match; locate the end with the tail.
+		tail := patchTail(s1)
+		endLoc, _ = b.match(dmp, tail, startLoc+len(s1)-len(tail))
+		if endLoc == -1 || startLoc >= endLoc {
+			return -1, -1, score, ApplyNoTrailingContext
+		}
+		return startLoc, endLoc + len(tail), score, ApplyOK
+	}
+	return startLoc, -1, score, ApplyOK
+}
+
+func patchApply(dmp *DMP, ps []Patch, s string, opts *ApplyOptions) (
+	string, []*PatchReport,
+) {
+	if len(ps) == 0 {
+		return s, []*PatchReport{}
+	}
+	if opts == nil {
+		opts = new(ApplyOptions)
+	}
+
+	// Deep copy the patches so that no changes are made to originals.
+	ps = PatchDeepCopy(ps)
+
+	nullPadding := patchAddPadding(ps, dmp.PatchMargin)
+	pad := len(nullPadding)
+	b := newEditBuffer(nullPadding + s + nullPadding)
+	maxBits := dmp.MatchMaxBits
+	ps = patchSplitMax(ps, maxBits, dmp.PatchMargin)
+
+	// delta keeps track of the offset between the expected and actual
+	// location of the previous patch.  If there are patches expected at
+	// positions 10 and 20, but the first patch was found at 12, delta is 2
+	// and the second patch has an effective expected position of 22.
+	delta := 0
+	reports := make([]*PatchReport, len(ps))
+	for x, p := range ps {
+		expectedLoc := p.start2 + delta
+		s1 := DiffText1(p.diffs)
+		startLoc, endLoc, score, failure := patchMatch(
+			dmp, b, s1, expectedLoc,
+		)
+		r := &PatchReport{
+			ExpectedLoc: max(0, expectedLoc-pad),
+			Loc:         -1,
+			Failure:     failure,
+		}
+		reports[x] = r
+		if failure == ApplyOK {
+			r.Loc = max(0, startLoc-pad)
+			r.Offset = startLoc - expectedLoc
+			r.Score = score
+			if opts.MaxScore > 0 && score > opts.MaxScore {
+				r.Failure = ApplyScoreThreshold
+			}
+		}
+		if r.Failure != ApplyOK {
+			// Subtract the delta for this failed patch from subsequent
+			// patches.
+			delta -= p.length2 - p.length1
+			continue
+		}
+
+		delta = startLoc - expectedLoc
+		if endLoc == -1 {
+			endLoc = startLoc + len(s1)
+		}
+		s2 := b.slice(startLoc, min(endLoc, b.Len()))
+		if s1 == s2 {
+			// Perfect match, just shove the Replacement text in.
+			b.replace(startLoc, startLoc+len(s1), DiffText2(p.diffs))
+			r.Applied = true
+			continue
+		}
+
+		// Imperfect match.  Run a diff to get a framework of equivalent
+		// indices.
+		diffs := dmp.DiffMain(s1, s2, false)
+		r.LevenshteinRatio = float64(DiffLevenshtein(diffs)) /
+			float64(len(s1))
+		long := len(s1) > maxBits
+		if maxBits <= 0 {
+			long = utf8.RuneCountInString(s1) > patchLongPattern
+		}
+		if long && r.LevenshteinRatio > dmp.PatchDeleteThreshold {
+			// The end points match, but the content is unacceptably bad.
+			r.Failure = ApplyDeleteThreshold
+			continue
+		}
+		diffs = DiffCleanupSemanticLossless(diffs)
+		patchApplyDiffs(b, startLoc, p.diffs, diffs)
+		r.Applied = true
+	}
+	// Strip the padding off.
+	return b.slice(pad, b.Len()-pad), reports
+}
+
+// patchApplyDiffs applies the diffs of a patch that matched imperfectly at
+// startLoc, using diffs between the expected and the actual text to map
+// the indexes.
+func patchApplyDiffs(b *editBuffer, startLoc int, pdiffs, diffs []Diff) {
+	index1 := 0
+	for _, d := range pdiffs {
+		if d.Type != Noop {
+			// The mapped indexes can run past the end of the text when the
+			// match is cut short by it.
+			start := min(startLoc+DiffXIndex(diffs, index1), b.Len())
+			if d.Type == Insert {
+				// Insertion
+				b.replace(start, start, d.Text)
+			} else if d.Type == Delete {
+				// Deletion
+				end := DiffXIndex(diffs, index1+len(d.Text))
+				b.replace(start, min(startLoc+end, b.Len()), "")
+			}
+		}
+		if d.Type != Delete {
+			index1 += len(d.Text)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package diffmp
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+// stringPatchMatch is patchMatch as it was before the edit buffer: it
+// matches in the whole text.
+func stringPatchMatch(dmp *DMP, s, s1 string, expectedLoc int) (
+	int, int, float64, ApplyFailure,
+) {
+	maxBits := dmp.MatchMaxBits
+	if maxBits > 0 && len(s1) > maxBits {
+		startLoc, score := matchMain(dmp, s, s1[:maxBits], expectedLoc)
+		if startLoc == -1 {
+			return -1, -1, score, ApplyNoMatch
+		}
+		endLoc := dmp.MatchMain(
+			s, s1[len(s1)-maxBits:], expectedLoc+len(s1)-maxBits,
+		)
+		if endLoc == -1 || startLoc >= endLoc {
+			return -1, -1, score, ApplyNoTrailingContext
+		}
+		return startLoc, endLoc + maxBits, score, ApplyOK
+	}
+
+	startLoc, score := matchMain(dmp, s, s1, expectedLoc)
+	if startLoc == -1 {
+		return -1, -1, score, ApplyNoMatch
+	}
+	if maxBits <= 0 && len(s1) > patchLongPattern &&
+		!strings.HasPrefix(s[startLoc:], s1) {
+		tail := patchTail(s1)
+		endLoc := dmp.MatchMain(s, tail, startLoc+len(s1)-len(tail))
+		if endLoc == -1 || startLoc >= endLoc {
+			return -1, -1, score, ApplyNoTrailingContext
+		}
+		return startLoc, endLoc + len(tail), score, ApplyOK
+	}
+	return startLoc, -1, score, ApplyOK
+}
+
+// stringPatchApply is patchApply as it was before the edit buffer: it
+// rebuilds the whole text with string concatenation for every edit. It is
+// kept as the reference that the edit buffer must agree with.
+func stringPatchApply(dmp *DMP, ps []Patch, s string) (
+	string, []*PatchReport,
+) {
+	if len(ps) == 0 {
+		return s, []*PatchReport{}
+	}
+	ps = PatchDeepCopy(ps)
+	nullPadding := patchAddPadding(ps, dmp.PatchMargin)
+	pad := len(nullPadding)
+	s = nullPadding + s + nullPadding
+	maxBits := dmp.MatchMaxBits
+	ps = patchSplitMax(ps, maxBits, dmp.PatchMargin)
+
+	delta := 0
+	reports := make([]*PatchReport, len(ps))
+	for x, p := range ps {
+		expectedLoc := p.start2 + delta
+		s1 := DiffText1(p.diffs)
+		startLoc, endLoc, score, failure := stringPatchMatch(
+			dmp, s, s1, expectedLoc,
+		)
+		r := &PatchReport{
+			ExpectedLoc: max(0, expectedLoc-pad),
+			Loc:         -1,
+			Failure:     failure,
+		}
+		reports[x] = r
+		if failure != ApplyOK {
+			delta -= p.length2 - p.length1
+			continue
+		}
+		r.Loc = max(0, startLoc-pad)
+		r.Offset = startLoc - expectedLoc
+		r.Score = score
+
+		delta = startLoc - expectedLoc
+		if endLoc == -1 {
+			endLoc = startLoc + len(s1)
+		}
+		s2 := s[startLoc:min(endLoc, len(s))]
+		if s1 == s2 {
+			s = s[:startLoc] + DiffText2(p.diffs) + s[startLoc+len(s1):]
+			r.Applied = true
+			continue
+		}
+
+		diffs := dmp.DiffMain(s1, s2, false)
+		r.LevenshteinRatio = float64(DiffLevenshtein(diffs)) /
+			float64(len(s1))
+		long := len(s1) > maxBits
+		if maxBits <= 0 {
+			long = utf8.RuneCountInString(s1) > patchLongPattern
+		}
+		if long && r.LevenshteinRatio > dmp.PatchDeleteThreshold {
+			r.Failure = ApplyDeleteThreshold
+			continue
+		}
+		diffs = DiffCleanupSemanticLossless(diffs)
+		index1 := 0
+		for _, d := range p.diffs {
+			if d.Type != Noop {
+				index2 := startLoc + DiffXIndex(diffs, index1)
+				if d.Type == Insert {
+					s = s[:index2] + d.Text + s[index2:]
+				} else {
+					end := startLoc + DiffXIndex(diffs, index1+len(d.Text))
+					s = s[:index2] + s[end:]
+				}
+			}
+			if d.Type != Delete {
+				index1 += len(d.Text)
+			}
+		}
+		r.Applied = true
+	}
+	return s[pad : len(s)-pad], reports
+}
+
+// randomEdit makes a few random word edits to s.
+func randomEdit(r *rand.Rand, s string, words []string) string {
+	n := 1 + r.Intn(4)
+	fields := strings.SplitAfter(s, " ")
+	for i := 0; i < n; i++ {
+		j := r.Intn(len(fields))
+		switch r.Intn(3) {
+		case 0:
+			fields[j] = ""
+		case 1:
+			fields[j] = words[r.Intn(len(words))]
+		default:
+			fields[j] += words[r.Intn(len(words))]
+		}
+	}
+	return strings.Join(fields, "")
+}
+
+func TestApplyMatchesStringApply(t *testing.T) {
+	r := rand.New(rand.NewSource(42))
+	words := []string{
+		"alpha ", "beta ", "gamma\n", "δέλτα ", "日本 ", "the ",
+		"quick ", "🅰 ", "x ",
+	}
+	randomText := func(n int) string {
+		var ws []string
+		for i := 0; i < n; i++ {
+			ws = append(ws, words[r.Intn(len(words))])
+		}
+		return strings.Join(ws, "")
+	}
+
+	for _, maxBits := range []int{32, 0} {
+		dmp := New()
+		dmp.MatchMaxBits = maxBits
+		for i := 0; i < 300; i++ {
+			text1 := randomText(20 + r.Intn(100))
+			text2 := randomEdit(r, text1, words)
+			patches := dmp.PatchMake(text1, text2)
+
+			// Apply on a text that is edited too, a little or a lot, so
+			// that patches also match fuzzily or fail.
+			base := text1
+			for j := 0; j < i%3*3; j++ {
+				base = randomEdit(r, base, words)
+			}
+			want, wantReports := stringPatchApply(dmp, patches, base)
+			got, reports := dmp.ApplyWithReport(patches, base, nil)
+			assert.Equal(t, want, got, "text %q", base)
+			assert.Equal(t, wantReports, reports, "text %q", base)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package diffmp
+
+import (
+	"fmt"
+)
+
+// NewPatch creates a patch that changes DiffText1(diffs) at byte offset
+// start1 of a text into DiffText2(diffs), which is at byte offset start2
+// of the patched text. The diffs usually start and end with some
+// unchanged context, which is used to locate the patch when the text has
+// changed. The diffs are copied.
+func NewPatch(start1, start2 int, diffs []Diff) (Patch, error) {
+	p := Patch{
+		diffs:  append([]Diff(nil), diffs...),
+		start1: start1,
+		start2: start2,
+	}
+	for _, d := range diffs {
+		if d.Type != Insert {
+			p.length1 += len(d.Text)
+		}
+		if d.Type != Delete {
+			p.length2 += len(d.Text)
+		}
+	}
+	if err := p.check(); err != nil {
+		return Patch{}, err
+	}
+	return p, nil
+}
+
+// check checks if the patch is valid.
+func (p *Patch) check() error {
+	if p.start1 < 0 || p.start2 < 0 {
+		return fmt.Errorf("negative patch start: %d, %d", p.start1, p.start2)
+	}
+	if len(p.diffs) == 0 {
+		return fmt.Errorf("patch has no diffs")
+	}
+	if err := ValidateDiffs(p.diffs); err != nil {
+		return err
+	}
+	length1 := len(DiffText1(p.diffs))
+	length2 := len(DiffText2(p.diffs))
+	if length1 != p.length1 || length2 != p.length2 {
+		return fmt.Errorf(
+			"patch lengths %d, %d do not match diffs %d, %d",
+			p.length1, p.length2, length1, length2,
+		)
+	}
+	return nil
+}
+
+// Diffs returns a copy of the diffs of the patch, including the context.
+func (p *Patch) Diffs() []Diff { return append([]Diff(nil), p.diffs...) }
+
+// Start1 returns the byte offset of the patch in the original text.
+func (p *Patch) Start1() int { return p.start1 }
+
+// Start2 returns the byte offset of the patch in the patched text.
+func (p *Patch) Start2() int { return p.start2 }
+
+// Length1 returns the length in bytes of the text that the patch changes,
+// including the context.
+func (p *Patch) Length1() int { return p.length1 }
+
+// Length2 returns the length in bytes of the text after the patch,
+// including the context.
+func (p *Patch) Length2() int { return p.length2 }
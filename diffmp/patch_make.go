@@ -0,0 +1,74 @@
+package diffmp
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// ValidateDiffs checks that diffs only have valid operations and UTF-8
+// text.
+func ValidateDiffs(diffs []Diff) error {
+	for i, d := range diffs {
+		switch d.Type {
+		case Delete, Insert, Noop:
+		default:
+			return fmt.Errorf("diff %d: invalid operation %d", i, d.Type)
+		}
+		if !utf8.ValidString(d.Text) {
+			return fmt.Errorf("diff %d: invalid UTF-8 text %q", i, d.Text)
+		}
+	}
+	return nil
+}
+
+// ValidatePatches checks that each patch has valid diffs, starts and
+// lengths.
+func ValidatePatches(ps []Patch) error {
+	for i := range ps {
+		if err := ps[i].check(); err != nil {
+			return fmt.Errorf("patch %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+func (dmp *DMP) patchDiffs(text1, text2 string) []Diff {
+	diffs := dmp.DiffMain(text1, text2, true)
+	if len(diffs) > 2 {
+		diffs = DiffCleanupSemantic(diffs)
+		diffs = dmp.DiffCleanupEfficiency(diffs)
+	}
+	return diffs
+}
+
+// PatchFromTexts makes patches that turn text1 into text2.
+func (dmp *DMP) PatchFromTexts(text1, text2 string) ([]Patch, error) {
+	if !utf8.ValidString(text1) || !utf8.ValidString(text2) {
+		return nil, fmt.Errorf("invalid UTF-8 text")
+	}
+	return patchMake2(dmp, text1, dmp.patchDiffs(text1, text2)), nil
+}
+
+// PatchFromDiffs makes patches from the diffs of two texts. The first text
+// is computed from the diffs.
+func (dmp *DMP) PatchFromDiffs(diffs []Diff) ([]Patch, error) {
+	if err := ValidateDiffs(diffs); err != nil {
+		return nil, err
+	}
+	return patchMake2(dmp, DiffText1(diffs), diffs), nil
+}
+
+// PatchFromTextAndDiffs makes patches from text1 and the diffs that turn
+// it into another text. It returns an error if text1 is not the first
+// text of the diffs.
+func (dmp *DMP) PatchFromTextAndDiffs(text1 string, diffs []Diff) (
+	[]Patch, error,
+) {
+	if err := ValidateDiffs(diffs); err != nil {
+		return nil, err
+	}
+	if DiffText1(diffs) != text1 {
+		return nil, fmt.Errorf("diffs do not match the text")
+	}
+	return patchMake2(dmp, text1, diffs), nil
+}
@@ -0,0 +1,54 @@
+package diffmp
+
+import (
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestPatchFrom(t *testing.T) {
+	dmp := New()
+	text1 := "The quick brown fox jumps over the lazy dog."
+	text2 := "That quick brown fox jumped over a lazy dog."
+	want := dmp.PatchMake(text1, text2)
+
+	ps, err := dmp.PatchFromTexts(text1, text2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, want, ps)
+	assert.Nil(t, ValidatePatches(ps))
+
+	diffs := dmp.DiffMain(text1, text2, false)
+	ps, err = dmp.PatchFromDiffs(diffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, dmp.PatchMake(diffs), ps)
+
+	ps, err = dmp.PatchFromTextAndDiffs(text1, diffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, dmp.PatchMake(text1, diffs), ps)
+
+	_, err = dmp.PatchFromTextAndDiffs(text2, diffs)
+	assert.NotNil(t, err)
+	_, err = dmp.PatchFromTexts(text1, "\xff")
+	assert.NotNil(t, err)
+
+	bad := []Diff{{Noop, "a"}, {Op(3), "b"}}
+	assert.NotNil(t, ValidateDiffs(bad))
+	_, err = dmp.PatchFromDiffs(bad)
+	assert.NotNil(t, err)
+
+	ps[0].length1++
+	assert.NotNil(t, ValidatePatches(ps))
+
+	// The context does not split characters.
+	ps, err = dmp.PatchFromTexts("日本語のテキスト", "日本語の文")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, ValidatePatches(ps))
+}
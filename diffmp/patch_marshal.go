@@ -0,0 +1,165 @@
+package diffmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+type jsonDiff struct {
+	// Op is " " for an equality, "-" for a deletion, and "+" for an
+	// insertion.
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// jsonPatch is the JSON form of a patch.
+type jsonPatch struct {
+	Start1  int         `json:"start1"`
+	Start2  int         `json:"start2"`
+	Length1 int         `json:"length1"`
+	Length2 int         `json:"length2"`
+	Diffs   []*jsonDiff `json:"diffs"`
+}
+
+func parseOp(s string) (Op, error) {
+	switch s {
+	case "+":
+		return Insert, nil
+	case "-":
+		return Delete, nil
+	case " ":
+		return Noop, nil
+	}
+	return 0, fmt.Errorf("invalid diff operation: %q", s)
+}
+
+// MarshalJSON marshals the patch into JSON. The offsets and lengths are
+// in bytes.
+func (p Patch) MarshalJSON() ([]byte, error) {
+	jp := &jsonPatch{
+		Start1:  p.start1,
+		Start2:  p.start2,
+		Length1: p.length1,
+		Length2: p.length2,
+	}
+	for _, d := range p.diffs {
+		jd := &jsonDiff{Op: opStr(d.Type), Text: d.Text}
+		jp.Diffs = append(jp.Diffs, jd)
+	}
+	return json.Marshal(jp)
+}
+
+// UnmarshalJSON unmarshals the patch from JSON, and checks if it is
+// valid.
+func (p *Patch) UnmarshalJSON(bs []byte) error {
+	jp := new(jsonPatch)
+	if err := json.Unmarshal(bs, jp); err != nil {
+		return err
+	}
+	ret := Patch{
+		start1:  jp.Start1,
+		start2:  jp.Start2,
+		length1: jp.Length1,
+		length2: jp.Length2,
+	}
+	for _, jd := range jp.Diffs {
+		op, err := parseOp(jd.Op)
+		if err != nil {
+			return err
+		}
+		ret.diffs = append(ret.diffs, Diff{op, jd.Text})
+	}
+	if err := ret.check(); err != nil {
+		return err
+	}
+	*p = ret
+	return nil
+}
+
+// patchBinaryVersion is the first byte of the binary form of a patch.
+const patchBinaryVersion = 1
+
+// MarshalBinary marshals the patch into a compact binary form. It starts
+// with a version byte, followed by the starts and the number of diffs in
+// uvarints. Each diff is then its operation in a varint, and its text,
+// prefixed with the length in a uvarint.
+func (p Patch) MarshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	var tmp [binary.MaxVarintLen64]byte
+	writeUvarint := func(x uint64) {
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], x)])
+	}
+
+	buf.WriteByte(patchBinaryVersion)
+	writeUvarint(uint64(p.start1))
+	writeUvarint(uint64(p.start2))
+	writeUvarint(uint64(len(p.diffs)))
+	for _, d := range p.diffs {
+		buf.Write(tmp[:binary.PutVarint(tmp[:], int64(d.Type))])
+		writeUvarint(uint64(len(d.Text)))
+		buf.WriteString(d.Text)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary unmarshals the patch from its binary form, and checks if
+// it is valid.
+func (p *Patch) UnmarshalBinary(bs []byte) error {
+	r := bytes.NewReader(bs)
+	version, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if version != patchBinaryVersion {
+		return fmt.Errorf("unknown patch version: %d", version)
+	}
+
+	var nums [3]uint64
+	for i := range nums {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		nums[i] = n
+	}
+	if nums[2] > uint64(r.Len()) {
+		return fmt.Errorf("too many diffs: %d", nums[2])
+	}
+
+	var diffs []Diff
+	for i := uint64(0); i < nums[2]; i++ {
+		op, err := binary.ReadVarint(r)
+		if err != nil {
+			return err
+		}
+		if op < int64(Delete) || op > int64(Insert) {
+			return fmt.Errorf("invalid diff operation: %d", op)
+		}
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		if n > uint64(r.Len()) {
+			return fmt.Errorf("diff text too long: %d", n)
+		}
+		text := make([]byte, n)
+		r.Read(text)
+		diffs = append(diffs, Diff{Op(op), string(text)})
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("%d trailing bytes", r.Len())
+	}
+
+	const maxInt = int(^uint(0) >> 1)
+	if nums[0] > uint64(maxInt) || nums[1] > uint64(maxInt) {
+		return fmt.Errorf("patch start out of range")
+	}
+	ret, err := NewPatch(int(nums[0]), int(nums[1]), diffs)
+	if err != nil {
+		return err
+	}
+	*p = ret
+	return nil
+}
@@ -0,0 +1,109 @@
+package diffmp
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestNewPatch(t *testing.T) {
+	diffs := []Diff{
+		{Noop, "jump"},
+		{Delete, "s"},
+		{Insert, "ed"},
+		{Noop, " over"},
+	}
+	p, err := NewPatch(20, 21, diffs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 20, p.Start1())
+	assert.Equal(t, 21, p.Start2())
+	assert.Equal(t, 10, p.Length1())
+	assert.Equal(t, 11, p.Length2())
+	assert.Equal(t, diffs, p.Diffs())
+	assert.Equal(t, "@@ -21,10 +22,11 @@\n jump\n-s\n+ed\n  over\n", p.String())
+
+	for _, bad := range []struct {
+		start1, start2 int
+		diffs          []Diff
+	}{
+		{-1, 0, diffs},
+		{0, 0, nil},
+		{0, 0, []Diff{{Op(2), "x"}}},
+		{0, 0, []Diff{{Insert, "\xff"}}},
+	} {
+		_, err := NewPatch(bad.start1, bad.start2, bad.diffs)
+		assert.NotNil(t, err, bad)
+	}
+}
+
+func TestPatchMarshal(t *testing.T) {
+	dmp := New()
+	patches := dmp.PatchMake(
+		"The quick brown fox jumps over the lazy dog.",
+		"That quick brown fox jumped over a lazy dog. 🐕",
+	)
+
+	bs, err := json.Marshal(patches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON []Patch
+	if err := json.Unmarshal(bs, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, patches, fromJSON)
+
+	for i := range patches {
+		bs, err := patches[i].MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := new(Patch)
+		if err := p.UnmarshalBinary(bs); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, patches[i], *p)
+
+		// Truncated input fails.
+		for n := 0; n < len(bs); n++ {
+			assert.NotNil(t, p.UnmarshalBinary(bs[:n]))
+		}
+	}
+
+	p, err := NewPatch(0, 0, []Diff{{Delete, "a"}, {Insert, "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"start1":0,"start2":0,"length1":1,"length2":1,` +
+		`"diffs":[{"op":"-","text":"a"},{"op":"+","text":"b"}]}`
+	bs, err = json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, want, string(bs))
+
+	// A patch value in a struct marshals the same, and round-trips.
+	type holder struct{ P Patch }
+	bs, err = json.Marshal(holder{p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `{"P":`+want+`}`, string(bs))
+	var h holder
+	if err := json.Unmarshal(bs, &h); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, p, h.P)
+
+	for _, bad := range []string{
+		`{"start1":0,"start2":0,"length1":2,"length2":1,` +
+			`"diffs":[{"op":"-","text":"a"},{"op":"+","text":"b"}]}`,
+		`{"start1":0,"start2":0,"length1":1,"length2":1,` +
+			`"diffs":[{"op":"-","text":"a"},{"op":"*","text":"b"}]}`,
+	} {
+		assert.NotNil(t, json.Unmarshal([]byte(bad), new(Patch)), bad)
+	}
+}
@@ -0,0 +1,172 @@
+package diffmp
+
+import (
+	"fmt"
+)
+
+// spanRest is the gap of a span that covers the rest of the text.
+const spanRest = -1
+
+// patchSpan is a diff of a patch list, or when gap is not zero, a gap of
+// unchanged text that the patches do not cover and only know the length
+// of.
+type patchSpan struct {
+	Diff
+	gap int
+}
+
+func (s *patchSpan) isGap() bool { return s.gap != 0 }
+
+// appendPatchSpan appends a span, and merges it into the last span if they
+// have the same type.
+func appendPatchSpan(spans []patchSpan, s patchSpan) []patchSpan {
+	if s.gap == 0 && s.Text == "" {
+		return spans
+	}
+	n := len(spans)
+	if n == 0 {
+		return append(spans, s)
+	}
+	last := &spans[n-1]
+	if s.isGap() && last.isGap() {
+		if s.gap == spanRest || last.gap == spanRest {
+			last.gap = spanRest
+		} else {
+			last.gap += s.gap
+		}
+		return spans
+	}
+	if !s.isGap() && !last.isGap() && s.Type == last.Type {
+		last.Text += s.Text
+		return spans
+	}
+	return append(spans, s)
+}
+
+// patchesToSpans converts a patch list to spans that cover the whole
+// text. Context that a patch shares with the patch before it is trimmed.
+func patchesToSpans(ps []Patch) ([]patchSpan, error) {
+	var spans []patchSpan
+	end := 0 // End of the last patch, as the next patch sees the text.
+	for i := range ps {
+		p := &ps[i]
+		diffs := p.diffs
+		gap := p.start1 - end
+		if gap < 0 {
+			// The patch shares text with the patch before; trim it off
+			// the leading context of this patch, or else off the trailing
+			// context of the patch before.
+			trim := -gap
+			if len(diffs) > 0 && diffs[0].Type == Noop {
+				n := min(trim, len(diffs[0].Text))
+				diffs = append([]Diff{
+					{Noop, diffs[0].Text[n:]},
+				}, diffs[1:]...)
+				trim -= n
+			}
+			var ok bool
+			if spans, ok = trimSpans(spans, trim); !ok {
+				return nil, fmt.Errorf(
+					"patch %d overlaps the edits of the patch before", i,
+				)
+			}
+			gap = 0
+		}
+		spans = appendPatchSpan(spans, patchSpan{gap: gap})
+		for _, d := range diffs {
+			spans = appendPatchSpan(spans, patchSpan{Diff: d})
+		}
+		end = p.start2 + p.length2
+	}
+	return appendPatchSpan(spans, patchSpan{gap: spanRest}), nil
+}
+
+// trimSpans trims n bytes of unchanged text off the end of spans.
+func trimSpans(spans []patchSpan, n int) ([]patchSpan, bool) {
+	if n == 0 {
+		return spans, true
+	}
+	last := len(spans) - 1
+	if last < 0 || spans[last].isGap() || spans[last].Type != Noop ||
+		len(spans[last].Text) < n {
+		return spans, false
+	}
+	text := spans[last].Text
+	if len(text) == n {
+		return spans[:last], true
+	}
+	spans[last].Text = text[:len(text)-n]
+	return spans, true
+}
+
+// spansToPatches groups the edits of spans into patches, with the known
+// unchanged text around the edits as context. Patches are split at gaps.
+func spansToPatches(spans []patchSpan) []Patch {
+	var ps []Patch
+	var cur *Patch
+	edited := false
+	flush := func() {
+		if cur != nil && edited {
+			ps = append(ps, *cur)
+		}
+		cur = nil
+		edited = false
+	}
+
+	pos := 0 // Position in the text with the patches before applied.
+	for _, s := range spans {
+		if s.isGap() {
+			flush()
+			if s.gap == spanRest {
+				break
+			}
+			pos += s.gap
+			continue
+		}
+		if cur == nil {
+			cur = &Patch{start1: pos, start2: pos}
+		}
+		cur.diffs = append(cur.diffs, s.Diff)
+		n := len(s.Text)
+		switch s.Type {
+		case Noop:
+			cur.length1 += n
+			cur.length2 += n
+			pos += n
+		case Delete:
+			cur.length1 += n
+			edited = true
+		case Insert:
+			cur.length2 += n
+			pos += n
+			edited = true
+		}
+	}
+	flush()
+	return ps
+}
+
+// orderEdits merges each run of edits between unchanged text into one
+// deletion followed by one insertion.
+func orderEdits(spans []patchSpan) []patchSpan {
+	var ret []patchSpan
+	var del, ins string
+	flush := func() {
+		ret = appendPatchSpan(ret, patchSpan{Diff: Diff{Delete, del}})
+		ret = appendPatchSpan(ret, patchSpan{Diff: Diff{Insert, ins}})
+		del, ins = "", ""
+	}
+	for _, s := range spans {
+		switch {
+		case s.isGap() || s.Type == Noop:
+			flush()
+			ret = appendPatchSpan(ret, s)
+		case s.Type == Delete:
+			del += s.Text
+		default:
+			ins += s.Text
+		}
+	}
+	flush()
+	return ret
+}
@@ -1,6 +1,9 @@
 package diffmp
 
 func patchSplitMax(ps []Patch, size, margin int) []Patch {
+	if size <= 0 {
+		return ps
+	}
 	for x := 0; x < len(ps); x++ {
 		cur := ps[x]
 		if cur.length1 <= size {
@@ -0,0 +1,86 @@
+package diffmp
+
+// rebasePatch applies p exactly at byte offset start of cur, which is the
+// text with the previous patches applied. If the text does not match, cur
+// is returned unchanged.
+func rebasePatch(cur string, p *Patch, start int) string {
+	text1 := DiffText1(p.diffs)
+	end := start + len(text1)
+	if end > len(cur) || cur[start:end] != text1 {
+		return cur
+	}
+	return cur[:start] + DiffText2(p.diffs) + cur[end:]
+}
+
+// unitOffsetOf returns the number of units in the first n bytes of s.
+// Bytes beyond the end of s count as one unit each.
+func unitOffsetOf(s string, n int, u Unit) int {
+	if n > len(s) {
+		return unitLen(s, u) + n - len(s)
+	}
+	return unitLen(s[:n], u)
+}
+
+// byteOffsetOf returns the byte offset of n units into s. Units beyond
+// the end of s count as one byte each.
+func byteOffsetOf(s string, n int, u Unit) int {
+	off, err := unitOffset(s, n, u)
+	if err == errOutOfBound {
+		return len(s) + n - unitLen(s, u)
+	}
+	return off
+}
+
+// patchesToUnit converts the offsets and lengths of patches from bytes to
+// unit u, on text with the previous patches applied.
+func patchesToUnit(patches []Patch, text string, u Unit) []Patch {
+	ret := PatchDeepCopy(patches)
+	cur := text
+	for i := range ret {
+		p := &ret[i]
+		next := rebasePatch(cur, p, p.start2)
+		p.start1 = unitOffsetOf(cur, p.start1, u)
+		p.start2 = unitOffsetOf(cur, p.start2, u)
+		p.length1 = unitLen(DiffText1(p.diffs), u)
+		p.length2 = unitLen(DiffText2(p.diffs), u)
+		cur = next
+	}
+	return ret
+}
+
+// patchesFromUnit converts the offsets and lengths of patches from unit u
+// to bytes, in the same way as patchesToUnit.
+func patchesFromUnit(patches []Patch, text string, u Unit) []Patch {
+	cur := text
+	for i := range patches {
+		p := &patches[i]
+		p.start1 = byteOffsetOf(cur, p.start1, u)
+		p.start2 = byteOffsetOf(cur, p.start2, u)
+		p.length1 = len(DiffText1(p.diffs))
+		p.length2 = len(DiffText2(p.diffs))
+		cur = rebasePatch(cur, p, p.start2)
+	}
+	return patches
+}
+
+// PatchToTextUnit works like PatchToText, but counts the offsets and
+// lengths in unit u. Use UnitUTF16 for the JavaScript and Java libraries.
+// The offsets are converted on text, the text that the patches are made
+// from, with the previous patches applied, as PatchMake makes them.
+func PatchToTextUnit(patches []Patch, text string, u Unit) string {
+	if u == UnitBytes {
+		return PatchToText(patches)
+	}
+	return PatchToText(patchesToUnit(patches, text, u))
+}
+
+// PatchFromTextUnit works like PatchFromText, but reads the offsets and
+// lengths in unit u, and converts them to bytes on text, in the same way
+// as PatchToTextUnit.
+func PatchFromTextUnit(s, text string, u Unit) ([]Patch, error) {
+	patches, err := PatchFromText(s)
+	if err != nil || u == UnitBytes {
+		return patches, err
+	}
+	return patchesFromUnit(patches, text, u), nil
+}
@@ -0,0 +1,181 @@
+package diffmp
+
+import (
+	"sort"
+)
+
+// Bias decides where a position maps to when text is inserted at it, or
+// when the text around it is replaced.
+type Bias int
+
+// Biases of positions.
+const (
+	// BiasLeft sticks to the text before the position; the position
+	// stays before inserted text.
+	BiasLeft Bias = iota
+
+	// BiasRight sticks to the text after the position; the position
+	// moves after inserted text.
+	BiasRight
+)
+
+// posSeg is a diff of a position map, with its start offsets in both
+// texts, and the bytes of text1 that are kept before it.
+type posSeg struct {
+	op    Op
+	start [2]int
+	n     int
+	kept  int
+}
+
+// size returns the length of the segment on side i.
+func (s *posSeg) size(i int) int {
+	if s.op == Noop || s.op == sideOp(i) {
+		return s.n
+	}
+	return 0
+}
+
+// sideOp returns the operation that only has text on side i.
+func sideOp(i int) Op {
+	if i == 0 {
+		return Delete
+	}
+	return Insert
+}
+
+// PosMap maps byte offsets and ranges between the two texts of a diff
+// list. A lookup takes O(log n) time for n diffs.
+type PosMap struct {
+	segs  []posSeg
+	lens  [2]int
+	index [2]*TextIndex
+}
+
+// NewPosMap creates a position map of diffs that turn text1 into text2.
+func NewPosMap(diffs []Diff) *PosMap {
+	m := new(PosMap)
+	kept := 0
+	for _, d := range diffs {
+		if d.Text == "" {
+			continue
+		}
+		s := posSeg{op: d.Type, start: m.lens, n: len(d.Text), kept: kept}
+		m.segs = append(m.segs, s)
+		m.lens[0] += s.size(0)
+		m.lens[1] += s.size(1)
+		if d.Type == Noop {
+			kept += s.n
+		}
+	}
+	m.index[0] = NewTextIndex(DiffText1(diffs))
+	m.index[1] = NewTextIndex(DiffText2(diffs))
+	return m
+}
+
+// Index1 returns the index of text1, to convert offsets of text1 to
+// other units and to lines and columns.
+func (m *PosMap) Index1() *TextIndex { return m.index[0] }
+
+// Index2 returns the index of text2.
+func (m *PosMap) Index2() *TextIndex { return m.index[1] }
+
+// mapPos maps offset p on side a to the other side.
+func (m *PosMap) mapPos(a, p int, bias Bias) int {
+	b := 1 - a
+	p = max(0, min(p, m.lens[a]))
+	if bias == BiasLeft {
+		// The first segment that reaches p.
+		k := sort.Search(len(m.segs), func(k int) bool {
+			s := &m.segs[k]
+			return s.start[a]+s.size(a) >= p
+		})
+		if k == len(m.segs) {
+			return m.lens[b]
+		}
+		s := &m.segs[k]
+		if s.op == Noop {
+			return s.start[b] + p - s.start[a]
+		}
+		return s.start[b]
+	}
+
+	// The last segment that starts at or before p.
+	k := sort.Search(len(m.segs), func(k int) bool {
+		return m.segs[k].start[a] > p
+	}) - 1
+	if k < 0 {
+		return 0
+	}
+	s := &m.segs[k]
+	if s.op == Noop {
+		return s.start[b] + min(p-s.start[a], s.n)
+	}
+	return s.start[b] + s.size(b)
+}
+
+// Map maps byte offset p of text1 to text2. Offsets in deleted text map
+// to where the deletion is.
+func (m *PosMap) Map(p int, bias Bias) int { return m.mapPos(0, p, bias) }
+
+// MapBack maps byte offset p of text2 to text1. Offsets in inserted text
+// map to where the insertion is.
+func (m *PosMap) MapBack(p int, bias Bias) int {
+	return m.mapPos(1, p, bias)
+}
+
+// keptBefore returns the bytes of unchanged text before offset p on side
+// a.
+func (m *PosMap) keptBefore(a, p int) int {
+	k := sort.Search(len(m.segs), func(k int) bool {
+		return m.segs[k].start[a] > p
+	}) - 1
+	if k < 0 {
+		return 0
+	}
+	s := &m.segs[k]
+	if s.op == Noop {
+		return s.kept + min(p-s.start[a], s.n)
+	}
+	return s.kept
+}
+
+// inRemoved tells if offset p on side a is strictly inside text that
+// only side a has.
+func (m *PosMap) inRemoved(a, p int) bool {
+	k := sort.Search(len(m.segs), func(k int) bool {
+		return m.segs[k].start[a] >= p
+	}) - 1
+	if k < 0 {
+		return false
+	}
+	s := &m.segs[k]
+	return s.op == sideOp(a) && p < s.start[a]+s.n
+}
+
+func (m *PosMap) mapRange(a, start, end int) (int, int, bool) {
+	if end < start {
+		start, end = end, start
+	}
+	start2 := m.mapPos(a, start, BiasRight)
+	end2 := max(start2, m.mapPos(a, end, BiasLeft))
+	if start == end {
+		return start2, end2, m.inRemoved(a, start)
+	}
+	removed := m.keptBefore(a, end) == m.keptBefore(a, start)
+	return start2, end2, removed
+}
+
+// MapRange maps a range of byte offsets of text1 to text2. Text inserted
+// at the ends of the range is not taken into the range. The returned bool
+// tells if all text of the range is deleted; an empty range is deleted
+// when it is inside deleted text.
+func (m *PosMap) MapRange(start, end int) (int, int, bool) {
+	return m.mapRange(0, start, end)
+}
+
+// MapRangeBack maps a range of byte offsets of text2 to text1. The
+// returned bool tells if all text of the range is inserted.
+func (m *PosMap) MapRangeBack(start, end int) (int, int, bool) {
+	return m.mapRange(1, start, end)
+}
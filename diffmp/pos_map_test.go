@@ -0,0 +1,135 @@
+package diffmp
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestPosMap(t *testing.T) {
+	// "abcXdef" -> "aYcdZef"
+	m := NewPosMap([]Diff{
+		{Noop, "a"},
+		{Delete, "b"},
+		{Insert, "Y"},
+		{Noop, "c"},
+		{Delete, "X"},
+		{Noop, "d"},
+		{Insert, "Z"},
+		{Noop, "ef"},
+	})
+	for _, test := range []struct {
+		p, left, right int
+	}{
+		{0, 0, 0},
+		{1, 1, 1},
+		{2, 1, 2},
+		{3, 3, 3},
+		{4, 3, 3},
+		{5, 4, 5},
+		{7, 7, 7},
+		{100, 7, 7},
+	} {
+		assert.Equal(t, test.left, m.Map(test.p, BiasLeft), test.p)
+		assert.Equal(t, test.right, m.Map(test.p, BiasRight), test.p)
+	}
+	assert.Equal(t, 1, m.MapBack(1, BiasLeft))
+	assert.Equal(t, 2, m.MapBack(1, BiasRight))
+	assert.Equal(t, 5, m.MapBack(4, BiasLeft))
+	assert.Equal(t, 5, m.MapBack(5, BiasLeft))
+
+	for _, test := range []struct {
+		start, end   int
+		start2, end2 int
+		deleted      bool
+	}{
+		{0, 7, 0, 7, false},
+		{1, 2, 1, 1, true},
+		{3, 4, 3, 3, true},
+		{3, 5, 3, 4, false},
+		{4, 6, 3, 6, false},
+		{5, 5, 5, 5, false},
+		{1, 1, 1, 1, false},
+		{2, 2, 2, 2, false},
+	} {
+		start2, end2, deleted := m.MapRange(test.start, test.end)
+		assert.Equal(t, test.start2, start2, test)
+		assert.Equal(t, test.end2, end2, test)
+		assert.Equal(t, test.deleted, deleted, test)
+	}
+
+	_, _, inserted := m.MapRangeBack(4, 5)
+	assert.True(t, inserted)
+	_, _, inserted = m.MapRangeBack(3, 5)
+	assert.False(t, inserted)
+}
+
+func TestPosMapDeleteInside(t *testing.T) {
+	m := NewPosMap([]Diff{{Noop, "a"}, {Delete, "bcd"}, {Noop, "e"}})
+	_, _, deleted := m.MapRange(2, 2)
+	assert.True(t, deleted)
+	_, _, deleted = m.MapRange(1, 1)
+	assert.False(t, deleted)
+}
+
+func TestPosMapRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	dmp := New()
+	text1 := "The quick brown fox jumps over the lazy dog."
+	text2 := "That quick brown fox jumped over a lazy dog!"
+	diffs := dmp.DiffMain(text1, text2, false)
+	m := NewPosMap(diffs)
+	for i := 0; i < 100; i++ {
+		p := r.Intn(len(text1) + 1)
+		assert.Equal(t, DiffXIndex(diffs, p), m.Map(p, BiasRight), p)
+	}
+}
+
+func TestTextIndex(t *testing.T) {
+	x := NewTextIndex("héllo\nwörld 🐕\n\nend")
+	assert.Equal(t, 4, x.Lines())
+	line, col, err := x.LineCol(8, UnitRunes)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, line)
+	assert.Equal(t, 1, col)
+
+	off, err := x.Offset(1, 6, UnitUTF16)
+	assert.Nil(t, err)
+	assert.Equal(t, 14, off)
+	_, err = x.Offset(1, 7, UnitUTF16)
+	assert.NotNil(t, err)
+	_, err = x.Offset(1, 20, UnitRunes)
+	assert.NotNil(t, err)
+
+	n, err := x.ToUnit(off, UnitUTF16)
+	assert.Nil(t, err)
+	assert.Equal(t, 12, n)
+	n, err = x.ToUnit(len("héllo\nwörld 🐕\n\n"), UnitRunes)
+	assert.Nil(t, err)
+	assert.Equal(t, 15, n)
+	off, err = x.FromUnit(15, UnitRunes)
+	assert.Nil(t, err)
+	assert.Equal(t, len("héllo\nwörld 🐕\n\n"), off)
+	assert.Equal(t, 18, x.Len(UnitRunes))
+
+	_, err = x.ToUnit(2, UnitRunes)
+	assert.NotNil(t, err)
+}
+
+func BenchmarkPosMap(b *testing.B) {
+	var diffs []Diff
+	for i := 0; i < 10000; i++ {
+		diffs = append(diffs,
+			Diff{Noop, "unchanged text "},
+			Diff{Delete, "old"},
+			Diff{Insert, "new!"},
+		)
+	}
+	m := NewPosMap(diffs)
+	n := len(DiffText1(diffs))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Map(i%n, BiasLeft)
+	}
+}
@@ -37,3 +37,16 @@ func runesEqual(r1, r2 []rune) bool {
 	}
 	return true
 }
+
+// Return the last index of pattern in target that is not after target[i].
+func runesLastIndexOf(target, pattern []rune, i int) int {
+	if i < 0 {
+		return -1
+	}
+	for j := min(i, len(target)-len(pattern)); j >= 0; j-- {
+		if runesEqual(target[j:j+len(pattern)], pattern) {
+			return j
+		}
+	}
+	return -1
+}
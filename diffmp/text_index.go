@@ -0,0 +1,132 @@
+package diffmp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TextIndex converts between byte offsets, offsets in other units, and
+// line and column positions of a text. Lines and columns count from 0,
+// and lines are split by "\n". A conversion takes O(log n) time to find
+// the line, and time linear in the length of the line.
+type TextIndex struct {
+	text  string
+	lines []int // byte offsets of line starts
+	runes []int // rune offsets of line starts
+	utf16 []int // UTF-16 offsets of line starts
+}
+
+// NewTextIndex indexes a text.
+func NewTextIndex(text string) *TextIndex {
+	x := &TextIndex{text: text}
+	start, nrunes, nutf16 := 0, 0, 0
+	for {
+		x.lines = append(x.lines, start)
+		x.runes = append(x.runes, nrunes)
+		x.utf16 = append(x.utf16, nutf16)
+		i := strings.IndexByte(text[start:], '\n')
+		if i < 0 {
+			break
+		}
+		line := text[start : start+i+1]
+		nrunes += unitLen(line, UnitRunes)
+		nutf16 += unitLen(line, UnitUTF16)
+		start += i + 1
+	}
+	return x
+}
+
+// Len returns the length of the text in unit u.
+func (x *TextIndex) Len(u Unit) int {
+	last := len(x.lines) - 1
+	return x.lineStart(last, u) + unitLen(x.text[x.lines[last]:], u)
+}
+
+// Lines returns the number of lines. A text that ends with "\n" has an
+// empty last line.
+func (x *TextIndex) Lines() int { return len(x.lines) }
+
+func (x *TextIndex) lineStart(line int, u Unit) int {
+	switch u {
+	case UnitRunes:
+		return x.runes[line]
+	case UnitUTF16:
+		return x.utf16[line]
+	}
+	return x.lines[line]
+}
+
+func (x *TextIndex) lineText(line int) string {
+	if line+1 < len(x.lines) {
+		return x.text[x.lines[line]:x.lines[line+1]]
+	}
+	return x.text[x.lines[line]:]
+}
+
+// lineOf returns the line that has byte offset off.
+func (x *TextIndex) lineOf(off int) int {
+	return sort.SearchInts(x.lines, off+1) - 1
+}
+
+func (x *TextIndex) checkOffset(off int) error {
+	if off < 0 || off > len(x.text) {
+		return fmt.Errorf("offset %d out of range [0, %d]", off, len(x.text))
+	}
+	if _, err := unitOffset(x.text, off, UnitBytes); err != nil {
+		return fmt.Errorf("offset %d: %s", off, err)
+	}
+	return nil
+}
+
+// ToUnit converts byte offset off to an offset in unit u.
+func (x *TextIndex) ToUnit(off int, u Unit) (int, error) {
+	if err := x.checkOffset(off); err != nil {
+		return 0, err
+	}
+	line := x.lineOf(off)
+	start := x.lines[line]
+	return x.lineStart(line, u) + unitLen(x.text[start:off], u), nil
+}
+
+// FromUnit converts offset n in unit u to a byte offset.
+func (x *TextIndex) FromUnit(n int, u Unit) (int, error) {
+	if n < 0 || n > x.Len(u) {
+		return 0, fmt.Errorf("offset %d out of range", n)
+	}
+	line := sort.Search(len(x.lines), func(i int) bool {
+		return x.lineStart(i, u) > n
+	}) - 1
+	start := x.lines[line]
+	off, err := unitOffset(x.lineText(line), n-x.lineStart(line, u), u)
+	if err != nil {
+		return 0, fmt.Errorf("offset %d: %s", n, err)
+	}
+	return start + off, nil
+}
+
+// LineCol converts byte offset off to a line and a column in unit u.
+func (x *TextIndex) LineCol(off int, u Unit) (line, col int, err error) {
+	if err := x.checkOffset(off); err != nil {
+		return 0, 0, err
+	}
+	line = x.lineOf(off)
+	return line, unitLen(x.text[x.lines[line]:off], u), nil
+}
+
+// Offset converts a line and a column in unit u to a byte offset. The
+// column may not go past the end of the line.
+func (x *TextIndex) Offset(line, col int, u Unit) (int, error) {
+	if line < 0 || line >= len(x.lines) {
+		return 0, fmt.Errorf("line %d out of range", line)
+	}
+	text := strings.TrimSuffix(x.lineText(line), "\n")
+	if col < 0 {
+		return 0, fmt.Errorf("invalid column %d", col)
+	}
+	off, err := unitOffset(text, col, u)
+	if err != nil {
+		return 0, fmt.Errorf("line %d column %d: %s", line, col, err)
+	}
+	return x.lines[line] + off, nil
+}
@@ -0,0 +1,58 @@
+package diffmp
+
+import (
+	"strings"
+)
+
+// TokenDiff is one diff operation on a sequence of token IDs. Unlike the
+// runes of DiffLinesToRunes, the IDs are never converted into a string, so
+// every int32 value is a valid ID.
+type TokenDiff struct {
+	Type Op
+	IDs  []int32
+}
+
+func spliceIDs(s []TokenDiff, off, n int, ds ...TokenDiff) []TokenDiff {
+	var ret []TokenDiff
+	ret = append(ret, s[:off]...)
+	ret = append(ret, ds...)
+	ret = append(ret, s[off+n:]...)
+	return ret
+}
+
+// tokenDiffsToDiffs converts diffs of runes into diffs of text.
+func tokenDiffsToDiffs(ds []TokenDiff) []Diff {
+	ret := make([]Diff, len(ds))
+	for i, d := range ds {
+		ret[i] = Diff{d.Type, string(d.IDs)}
+	}
+	return ret
+}
+
+// diffsToTokenDiffs converts diffs of text into diffs of runes.
+func diffsToTokenDiffs(ds []Diff) []TokenDiff {
+	ret := make([]TokenDiff, len(ds))
+	for i, d := range ds {
+		ret[i] = TokenDiff{d.Type, []rune(d.Text)}
+	}
+	return ret
+}
+
+// DiffIDsToTokens maps the token IDs in diffs back to text, where the
+// ID of tokens[i] is i.
+func DiffIDsToTokens(diffs []TokenDiff, tokens []string) []Diff {
+	ret := make([]Diff, len(diffs))
+	for i, d := range diffs {
+		var b strings.Builder
+		for _, id := range d.IDs {
+			b.WriteString(tokens[id])
+		}
+		ret[i] = Diff{d.Type, b.String()}
+	}
+	return ret
+}
+
+// DiffMainIDs finds the differences between two sequences of token IDs.
+func (dmp *DMP) DiffMainIDs(ids1, ids2 []int32) []TokenDiff {
+	return diffMainIDs(dmp, ids1, ids2, false, newDiffRun(nil, dmp))
+}
@@ -0,0 +1,54 @@
+package diffmp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestDiffMainIDs(t *testing.T) {
+	dmp := New()
+	// IDs that are surrogates or beyond the Unicode range.
+	a := []int32{1, 0xD800, 0xDFFF, 0x110000, 7}
+	b := []int32{1, 0xDFFF, 0x7FFFFFFF, 0x110000, 7}
+	assert.Equal(t, []TokenDiff{
+		{Noop, []int32{1}},
+		{Delete, []int32{0xD800}},
+		{Noop, []int32{0xDFFF}},
+		{Insert, []int32{0x7FFFFFFF}},
+		{Noop, []int32{0x110000, 7}},
+	}, dmp.DiffMainIDs(a, b))
+
+	// The inputs are not changed.
+	assert.Equal(t, []int32{1, 0xD800, 0xDFFF, 0x110000, 7}, a)
+}
+
+func TestDiffManyLines(t *testing.T) {
+	// More unique lines than there are runes before the surrogates.
+	var lines []string
+	for i := 0; i < 70000; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i))
+	}
+	a := strings.Join(lines, "")
+	lines[60000] = "changed\n"
+	lines = append(lines[:50000], lines[50001:]...)
+	b := strings.Join(lines, "")
+
+	dmp := New()
+	dmp.DiffTimeout = 0
+	want := []Diff{
+		{Noop, strings.Join(lines[:50000], "")},
+		{Delete, "line 50000\n"},
+		{Noop, strings.Join(lines[50000:59999], "")},
+		{Delete, "line 60000\n"},
+		{Insert, "changed\n"},
+		{Noop, strings.Join(lines[60000:], "")},
+	}
+	assert.Equal(t, want, dmp.DiffMainTokens(a, b, LineTokenizer))
+
+	diffs := dmp.DiffMain(a, b, true)
+	assert.Equal(t, a, DiffText1(diffs))
+	assert.Equal(t, b, DiffText2(diffs))
+}
@@ -0,0 +1,53 @@
+package diffmp
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// appendSpan appends a span of source code, with its trailing white space
+// as a separate token.
+func appendSpan(tokens []string, span string) []string {
+	trimmed := strings.TrimRightFunc(span, unicode.IsSpace)
+	if trimmed != "" {
+		tokens = append(tokens, trimmed)
+	}
+	if len(trimmed) < len(span) {
+		tokens = append(tokens, span[len(trimmed):])
+	}
+	return tokens
+}
+
+func splitGo(s string) []string {
+	src := []byte(s)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var sc scanner.Scanner
+	// Errors are ignored: invalid code still splits into some tokens, and
+	// the tokens always cover the whole text.
+	sc.Init(file, src, nil, scanner.ScanComments)
+
+	var tokens []string
+	start := 0
+	for {
+		pos, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue // Automatically inserted.
+		}
+		offset := file.Offset(pos)
+		if offset > start {
+			tokens = appendSpan(tokens, s[start:offset])
+		}
+		start = offset
+	}
+	if start < len(s) {
+		tokens = appendSpan(tokens, s[start:])
+	}
+	return tokens
+}
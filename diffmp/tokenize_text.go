@@ -0,0 +1,103 @@
+package diffmp
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) ||
+		unicode.Is(unicode.Mn, r)
+}
+
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// runeClass classifies runes for splitWords. Runes of class 0 are always
+// tokens on their own.
+func runeClass(r rune) int {
+	switch {
+	case isIdeograph(r):
+		return 0
+	case isWordRune(r):
+		return 1
+	case unicode.IsSpace(r):
+		return 2
+	}
+	return 0
+}
+
+func splitWords(s string) []string {
+	var tokens []string
+	start := 0
+	for start < len(s) {
+		r, size := utf8.DecodeRuneInString(s[start:])
+		end := start + size
+		if class := runeClass(r); class != 0 {
+			for end < len(s) {
+				r, size := utf8.DecodeRuneInString(s[end:])
+				if runeClass(r) != class {
+					break
+				}
+				end += size
+			}
+		}
+		tokens = append(tokens, s[start:end])
+		start = end
+	}
+	return tokens
+}
+
+// sentenceEnd returns the end of the sentence that has its ending
+// punctuation at s[i], or -1 if the sentence does not end there.
+func sentenceEnd(s string, i int) int {
+	j := i + 1
+	for j < len(s) && strings.IndexByte(".!?", s[j]) >= 0 {
+		j++
+	}
+	for j < len(s) {
+		r, size := utf8.DecodeRuneInString(s[j:])
+		if !strings.ContainsRune(`"')]”’»`, r) {
+			break
+		}
+		j += size
+	}
+	if j == len(s) {
+		return j
+	}
+	r, _ := utf8.DecodeRuneInString(s[j:])
+	if !unicode.IsSpace(r) {
+		return -1
+	}
+	return j
+}
+
+func splitSentences(s string) []string {
+	var tokens []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		end := -1
+		switch s[i] {
+		case '.', '!', '?':
+			end = sentenceEnd(s, i)
+		case '\n':
+			if strings.HasPrefix(strings.TrimLeft(s[i+1:], " \t\r"), "\n") {
+				end = i + 1
+			}
+		}
+		if end < 0 {
+			continue
+		}
+		rest := strings.TrimLeftFunc(s[end:], unicode.IsSpace)
+		end = len(s) - len(rest)
+		tokens = append(tokens, s[start:end])
+		start = end
+		i = end - 1
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+	return tokens
+}
@@ -0,0 +1,92 @@
+package diffmp
+
+import (
+	"strings"
+)
+
+// Tokenizer splits a text into tokens for DiffMainTokens. Joining the
+// tokens must give back the text.
+type Tokenizer interface {
+	Tokens(s string) []string
+}
+
+// TokenizerFunc is a function that works as a Tokenizer.
+type TokenizerFunc func(s string) []string
+
+// Tokens calls f(s).
+func (f TokenizerFunc) Tokens(s string) []string { return f(s) }
+
+// Built-in tokenizers.
+var (
+	// LineTokenizer splits text into lines, keeping the line endings.
+	LineTokenizer Tokenizer = TokenizerFunc(splitLines)
+
+	// WordTokenizer splits text into words, white space runs and
+	// punctuation characters. Han, Hiragana and Katakana characters are
+	// tokens on their own.
+	WordTokenizer Tokenizer = TokenizerFunc(splitWords)
+
+	// SentenceTokenizer splits text into sentences. A sentence ends after
+	// '.', '!' or '?' and the closing quotes and brackets that follow,
+	// when white space comes next; the white space is part of the
+	// sentence. A blank line also ends a sentence.
+	SentenceTokenizer Tokenizer = TokenizerFunc(splitSentences)
+
+	// GoTokenizer splits Go source code into the tokens of go/scanner,
+	// comments and white space runs.
+	GoTokenizer Tokenizer = TokenizerFunc(splitGo)
+)
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffTokensToIDsMunge maps each token to an ID, adding new tokens to
+// tokenArray and tokenHash.
+func diffTokensToIDsMunge(
+	tokens []string, tokenArray *[]string, tokenHash map[string]int32,
+) []int32 {
+	ids := make([]int32, 0, len(tokens))
+	for _, tok := range tokens {
+		v, ok := tokenHash[tok]
+		if !ok {
+			*tokenArray = append(*tokenArray, tok)
+			v = int32(len(*tokenArray) - 1)
+			tokenHash[tok] = v
+		}
+		ids = append(ids, v)
+	}
+	return ids
+}
+
+// DiffTokensToIDs splits two texts into tokens with t, and returns an ID
+// for each token, together with the token array that DiffIDsToTokens
+// uses to map the IDs back to text.
+func DiffTokensToIDs(s1, s2 string, t Tokenizer) (
+	[]int32, []int32, []string,
+) {
+	// '\x00' is a valid character, but various debuggers don't like it.
+	// So we'll insert a junk entry to avoid generating a null character.
+	tokenArray := []string{""}
+	tokenHash := make(map[string]int32)
+
+	ids1 := diffTokensToIDsMunge(t.Tokens(s1), &tokenArray, tokenHash)
+	ids2 := diffTokensToIDsMunge(t.Tokens(s2), &tokenArray, tokenHash)
+	return ids1, ids2, tokenArray
+}
+
+// DiffMainTokens finds the differences between two texts token by token,
+// so that no token is ever split. The diffs are in ordinary text, and no
+// semantic cleanup is done on them.
+func (dmp *DMP) DiffMainTokens(s1, s2 string, t Tokenizer) []Diff {
+	ids1, ids2, tokens := DiffTokensToIDs(s1, s2, t)
+	diffs := diffMainIDs(dmp, ids1, ids2, false, newDiffRun(nil, dmp))
+	return DiffIDsToTokens(diffs, tokens)
+}
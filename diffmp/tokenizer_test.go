@@ -0,0 +1,83 @@
+package diffmp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestTokenizers(t *testing.T) {
+	for _, test := range []struct {
+		t    Tokenizer
+		s    string
+		want []string
+	}{
+		{LineTokenizer, "", nil},
+		{LineTokenizer, "a\nb", []string{"a\n", "b"}},
+		{LineTokenizer, "a\n\n", []string{"a\n", "\n"}},
+		{
+			WordTokenizer, "Don't panic,  café!\n",
+			[]string{
+				"Don", "'", "t", " ", "panic", ",", "  ", "café", "!", "\n",
+			},
+		},
+		{WordTokenizer, "漢字 ok", []string{"漢", "字", " ", "ok"}},
+		{
+			SentenceTokenizer, `He said "Hi." Then left... Why?  3.14 ok`,
+			[]string{`He said "Hi." `, "Then left... ", "Why?  ", "3.14 ok"},
+		},
+		{
+			SentenceTokenizer, "Title\n\nBody\ntext",
+			[]string{"Title\n\n", "Body\ntext"},
+		},
+		{
+			GoTokenizer, "x := a[i] // c\r\n\tf(`r`)\n",
+			[]string{
+				"x", " ", ":=", " ", "a", "[", "i", "]", " ", "// c",
+				"\r\n\t", "f", "(", "`r`", ")", "\n",
+			},
+		},
+		{GoTokenizer, "x = 'ab @", []string{"x", " ", "=", " ", "'ab @"}},
+	} {
+		got := test.t.Tokens(test.s)
+		assert.Equal(t, test.want, got, test.s)
+		assert.Equal(t, test.s, strings.Join(got, ""), test.s)
+	}
+}
+
+func TestDiffMainTokens(t *testing.T) {
+	dmp := New()
+	diffs := dmp.DiffMainTokens(
+		"The cat sat on the mat.", "The cat sits on a mat.", WordTokenizer,
+	)
+	assert.Equal(t, []Diff{
+		{Noop, "The cat "},
+		{Delete, "sat"},
+		{Insert, "sits"},
+		{Noop, " on "},
+		{Delete, "the"},
+		{Insert, "a"},
+		{Noop, " mat."},
+	}, diffs)
+
+	diffs = dmp.DiffMainTokens(
+		"One. Two. Three.", "One. Too. Three.", SentenceTokenizer,
+	)
+	assert.Equal(t, []Diff{
+		{Noop, "One. "},
+		{Delete, "Two. "},
+		{Insert, "Too. "},
+		{Noop, "Three."},
+	}, diffs)
+
+	diffs = dmp.DiffMainTokens(
+		"return x+1\n", "return xs+1\n", GoTokenizer,
+	)
+	assert.Equal(t, []Diff{
+		{Noop, "return "},
+		{Delete, "x"},
+		{Insert, "xs"},
+		{Noop, "+1\n"},
+	}, diffs)
+}
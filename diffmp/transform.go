@@ -0,0 +1,114 @@
+package diffmp
+
+import (
+	"fmt"
+)
+
+// diffCursor walks a diff list, and can take part of a diff.
+type diffCursor struct {
+	diffs []Diff
+	i     int
+	off   int // bytes of diffs[i].Text already taken
+}
+
+func (c *diffCursor) done() bool { return c.i >= len(c.diffs) }
+
+func (c *diffCursor) peek() (Op, string) {
+	d := c.diffs[c.i]
+	return d.Type, d.Text[c.off:]
+}
+
+// take takes n bytes of the current diff.
+func (c *diffCursor) take(n int) string {
+	d := c.diffs[c.i]
+	s := d.Text[c.off : c.off+n]
+	c.off += n
+	if c.off >= len(d.Text) {
+		c.i++
+		c.off = 0
+	}
+	return s
+}
+
+// skipEmpty skips diffs with no text.
+func (c *diffCursor) skipEmpty() {
+	for !c.done() && c.diffs[c.i].Text[c.off:] == "" {
+		c.i++
+		c.off = 0
+	}
+}
+
+// appendMerged appends a diff, and merges it into the last diff if they
+// have the same type.
+func appendMerged(diffs []Diff, t Op, s string) []Diff {
+	if s == "" {
+		return diffs
+	}
+	if n := len(diffs); n > 0 && diffs[n-1].Type == t {
+		diffs[n-1].Text += s
+		return diffs
+	}
+	return append(diffs, Diff{t, s})
+}
+
+// DiffTransform transforms diffs a over diffs b, where a and b are
+// concurrent edits on the same text. The result does the change of a on
+// the text that b produces, so that applying a and then
+// DiffTransform(b, a, !aFirst) gives the same text as applying b and then
+// DiffTransform(a, b, aFirst).
+//
+// When a and b insert at the same position, the insertion of a goes first
+// if aFirst is true. Text that both a and b delete is deleted once, and
+// text that b inserts inside a deletion of a is kept.
+//
+// It returns an error if a and b are not edits of the same text.
+func DiffTransform(a, b []Diff, aFirst bool) ([]Diff, error) {
+	if t1, t2 := DiffText1(a), DiffText1(b); t1 != t2 {
+		return nil, fmt.Errorf(
+			"diffs have different source texts: %q and %q", t1, t2,
+		)
+	}
+
+	var ret []Diff
+	ca := &diffCursor{diffs: a}
+	cb := &diffCursor{diffs: b}
+	for {
+		ca.skipEmpty()
+		cb.skipEmpty()
+		if ca.done() && cb.done() {
+			break
+		}
+
+		var opA, opB Op
+		var textA, textB string
+		if !ca.done() {
+			opA, textA = ca.peek()
+		}
+		if !cb.done() {
+			opB, textB = cb.peek()
+		}
+		insA := !ca.done() && opA == Insert
+		insB := !cb.done() && opB == Insert
+		if insA && (!insB || aFirst) {
+			ret = appendMerged(ret, Insert, ca.take(len(textA)))
+			continue
+		}
+		if insB {
+			// Text inserted by b is kept as is.
+			ret = appendMerged(ret, Noop, cb.take(len(textB)))
+			continue
+		}
+
+		// Both a and b consume the source text; the source texts are
+		// the same, so neither runs out before the other.
+		n := min(len(textA), len(textB))
+		s := ca.take(n)
+		cb.take(n)
+		if opB == Delete {
+			// Already deleted by b.
+			continue
+		}
+		ret = appendMerged(ret, opA, s)
+	}
+	return ret, nil
+}
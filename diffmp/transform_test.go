@@ -0,0 +1,59 @@
+package diffmp
+
+import (
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func assertTransformConverges(
+	t *testing.T, base, textA, textB, want string,
+) {
+	dmp := New()
+	a := dmp.DiffMain(base, textA, false)
+	b := dmp.DiffMain(base, textB, false)
+
+	a2, err := DiffTransform(a, b, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := DiffTransform(b, a, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, textB, DiffText1(a2), "a' applies on b")
+	assert.Equal(t, textA, DiffText1(b2), "b' applies on a")
+	assert.Equal(t, want, DiffText2(a2), "b then a'")
+	assert.Equal(t, want, DiffText2(b2), "a then b'")
+}
+
+func TestDiffTransform(t *testing.T) {
+	for _, test := range []struct {
+		base, a, b, want string
+	}{
+		// Edits in different places.
+		{"hello world", "hello, world", "hello world!", "hello, world!"},
+		// Concurrent inserts at the same position; a goes first.
+		{"ab", "aXb", "aYb", "aXYb"},
+		{"", "x", "y", "xy"},
+		// Overlapping deletes.
+		{"abcdef", "af", "abf", "af"},
+		{"abcdef", "abef", "abef", "abef"},
+		// Insert inside a delete is kept.
+		{"abcdef", "af", "abcXdef", "aXf"},
+		// Replacements of the same text.
+		{"the cat", "the dog", "the cow", "the dogow"},
+		// Unicode.
+		{"日本語", "日本の語", "日語", "日の語"},
+	} {
+		assertTransformConverges(t, test.base, test.a, test.b, test.want)
+	}
+}
+
+func TestDiffTransformMismatch(t *testing.T) {
+	a := []Diff{{Noop, "abc"}}
+	b := []Diff{{Noop, "abd"}}
+	_, err := DiffTransform(a, b, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package diffmp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// noNewline is the marker of a last line that has no line ending.
+const noNewline = "\n\\ No newline at end of file\n"
+
+func formatHunkRange(start, n int) string {
+	if n == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+// WriteUnified writes hunks as a unified diff, which tools like patch and
+// git apply understand. The "---" and "+++" file header lines are only
+// written when there are hunks.
+func WriteUnified(w io.Writer, name1, name2 string, hunks []*LineHunk) error {
+	if len(hunks) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", name1, name2); err != nil {
+		return err
+	}
+	for _, h := range hunks {
+		_, err := fmt.Fprintf(
+			w, "@@ -%s +%s @@\n",
+			formatHunkRange(h.OldStart, h.OldLines),
+			formatHunkRange(h.NewStart, h.NewLines),
+		)
+		if err != nil {
+			return err
+		}
+		for _, line := range h.Lines {
+			if !strings.HasSuffix(line, "\n") {
+				line += noNewline
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DiffUnified converts diffs into a unified diff of whole lines, with
+// context lines of unchanged text around the changes.
+func DiffUnified(diffs []Diff, name1, name2 string, context int) string {
+	buf := new(bytes.Buffer)
+	hunks := DiffLineHunks(diffs, context)
+	if err := WriteUnified(buf, name1, name2, hunks); err != nil {
+		panic(err) // Writing to a buffer does not fail.
+	}
+	return buf.String()
+}
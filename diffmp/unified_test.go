@@ -0,0 +1,74 @@
+package diffmp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func numberedLines(n int) []string {
+	var lines []string
+	for i := 1; i <= n; i++ {
+		lines = append(lines, fmt.Sprintf("line %d\n", i))
+	}
+	return lines
+}
+
+func TestDiffUnified(t *testing.T) {
+	dmp := New()
+	lines := numberedLines(12)
+	text1 := strings.Join(lines, "")
+	lines[1] = "line two\n"
+	lines = append(lines[:9], append([]string{"new\n"}, lines[9:]...)...)
+	text2 := strings.Join(lines, "")
+
+	diffs := dmp.DiffMain(text1, text2, false)
+	assert.Equal(t,
+		"--- a\n+++ b\n"+
+			"@@ -1,5 +1,5 @@\n"+
+			" line 1\n-line 2\n+line two\n line 3\n line 4\n line 5\n"+
+			"@@ -7,6 +7,7 @@\n"+
+			" line 7\n line 8\n line 9\n+new\n line 10\n line 11\n line 12\n",
+		DiffUnified(diffs, "a", "b", 3),
+	)
+
+	a, b, lineArray := DiffLinesToChars(text1, text2)
+	lineDiffs := DiffCharsToLines(dmp.DiffMain(a, b, false), lineArray)
+	assert.Equal(t,
+		DiffUnified(diffs, "a", "b", 3),
+		DiffUnified(lineDiffs, "a", "b", 3),
+	)
+
+	hunks := DiffLineHunks(diffs, 5)
+	assert.Equal(t, 1, len(hunks))
+	assert.Equal(t, 0, len(DiffLineHunks(dmp.DiffMain(a, a, false), 3)))
+	assert.Equal(t, "", DiffUnified(nil, "a", "b", 3))
+}
+
+func TestDiffUnifiedEdges(t *testing.T) {
+	dmp := New()
+	for _, test := range []struct {
+		text1, text2, want string
+	}{{
+		"a\nb", "a\nb\n",
+		"@@ -2 +2 @@\n-b\n\\ No newline at end of file\n+b\n",
+	}, {
+		"a\nb\n", "x\na\nb\n",
+		"@@ -0,0 +1 @@\n+x\n",
+	}, {
+		"a\nb\n", "a\n",
+		"@@ -2 +1,0 @@\n-b\n",
+	}, {
+		"", "a",
+		"@@ -0,0 +1 @@\n+a\n\\ No newline at end of file\n",
+	}, {
+		"a b\nc\n", "a c\nc\n",
+		"@@ -1 +1 @@\n-a b\n+a c\n",
+	}} {
+		diffs := dmp.DiffMain(test.text1, test.text2, false)
+		got := DiffUnified(diffs, "x", "y", 0)
+		assert.Equal(t, "--- x\n+++ y\n"+test.want, got)
+	}
+}
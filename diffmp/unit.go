@@ -0,0 +1,100 @@
+package diffmp
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+// Unit is the unit of the offsets and lengths in deltas and patch text.
+type Unit int
+
+// Units of offsets and lengths.
+const (
+	// UnitRunes counts Unicode code points. FromDelta and DiffToDelta use
+	// it.
+	UnitRunes Unit = iota
+
+	// UnitBytes counts bytes in UTF-8. Patch uses it.
+	UnitBytes
+
+	// UnitUTF16 counts UTF-16 code units, as the JavaScript and Java
+	// diff-match-patch libraries do. Characters outside of the Basic
+	// Multilingual Plane, like most emoji, count as two.
+	UnitUTF16
+)
+
+// unitRaw counts bytes like UnitBytes, for raw bytes that are not always
+// UTF-8.
+const unitRaw Unit = -1
+
+var (
+	errOutOfBound = errors.New("index out of bound")
+	errSplitChar  = errors.New("offset splits a character")
+)
+
+func runeUnits(r rune, u Unit) int {
+	switch u {
+	case UnitBytes:
+		return utf8.RuneLen(r)
+	case UnitUTF16:
+		if r > 0xFFFF {
+			return 2
+		}
+	}
+	return 1
+}
+
+// unitLen returns the length of s in unit u.
+func unitLen(s string, u Unit) int {
+	switch u {
+	case UnitBytes, unitRaw:
+		return len(s)
+	case UnitRunes:
+		return utf8.RuneCountInString(s)
+	}
+	n := 0
+	for _, r := range s {
+		n += runeUnits(r, u)
+	}
+	return n
+}
+
+// unitOffset returns the byte offset of n units into s. When n is beyond
+// the end of s, or in the middle of a character, it returns an error,
+// together with the offset of the end of s, or the start of the
+// character.
+func unitOffset(s string, n int, u Unit) (int, error) {
+	if u == unitRaw {
+		if n > len(s) {
+			return len(s), errOutOfBound
+		}
+		return n, nil
+	}
+	if u == UnitBytes {
+		if n > len(s) {
+			return len(s), errOutOfBound
+		}
+		if n == len(s) || utf8.RuneStart(s[n]) {
+			return n, nil
+		}
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		return n, errSplitChar
+	}
+
+	count := 0
+	for i, r := range s {
+		if count == n {
+			return i, nil
+		}
+		count += runeUnits(r, u)
+		if count > n {
+			return i, errSplitChar
+		}
+	}
+	if count < n {
+		return len(s), errOutOfBound
+	}
+	return len(s), nil
+}
@@ -0,0 +1,94 @@
+package diffmp
+
+import (
+	"testing"
+
+	"github.com/stretchrcom/testify/assert"
+)
+
+func TestDeltaUnits(t *testing.T) {
+	diffs := []Diff{
+		{Noop, "🅰"},
+		{Insert, "🅱"},
+		{Noop, "x"},
+		{Delete, "日本"},
+	}
+	text1 := DiffText1(diffs)
+	for _, test := range []struct {
+		u     Unit
+		delta string
+	}{
+		{UnitRunes, "=1\t+%F0%9F%85%B1\t=1\t-2"},
+		{UnitBytes, "=4\t+%F0%9F%85%B1\t=1\t-6"},
+		{UnitUTF16, "=2\t+%F0%9F%85%B1\t=1\t-2"},
+	} {
+		delta := DiffToDeltaUnit(diffs, test.u)
+		assert.Equal(t, test.delta, delta)
+		got, err := FromDeltaUnit(text1, delta, test.u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, diffs, got)
+	}
+
+	// The test vector of the JavaScript and Java libraries.
+	diffs = []Diff{
+		{Noop, "\u0680 \x00 \t %"},
+		{Delete, "\u0681 \x01 \n ^"},
+		{Insert, "\u0682 \x02 \\ |"},
+	}
+	delta := DiffToDeltaUnit(diffs, UnitUTF16)
+	assert.Equal(t, "=7\t-7\t+%DA%82 %02 %5C %7C", delta)
+
+	for _, test := range []struct {
+		u     Unit
+		delta string
+	}{
+		{UnitUTF16, "=1\t=3"},
+		{UnitBytes, "=2\t=6"},
+		{UnitUTF16, "=6"},
+		{UnitUTF16, "=3"},
+	} {
+		_, err := FromDeltaUnit("🅰x日本", test.delta, test.u)
+		assert.NotNil(t, err, test.delta)
+	}
+}
+
+func TestPatchTextUnits(t *testing.T) {
+	dmp := New()
+	text1 := "🅰🅰🅰 The quick brown fox\n🅱 jumps over the lazy dog."
+	text2 := "🅰🅰🅰 The quick red fox\n🅱 jumps over the 🅲 dog."
+	patches := dmp.PatchMake(text1, text2)
+
+	for _, test := range []struct {
+		u    Unit
+		want string
+	}{
+		{
+			UnitBytes,
+			"@@ -20,13 +20,11 @@\n ick \n-brown\n+red\n  fox\n" +
+				"@@ -48,12 +48,12 @@\n the \n-lazy\n+%F0%9F%85%B2\n  dog\n",
+		},
+		{
+			UnitRunes,
+			"@@ -11,13 +11,11 @@\n ick \n-brown\n+red\n  fox\n" +
+				"@@ -36,12 +36,9 @@\n the \n-lazy\n+%F0%9F%85%B2\n  dog\n",
+		},
+		{
+			UnitUTF16,
+			"@@ -14,13 +14,11 @@\n ick \n-brown\n+red\n  fox\n" +
+				"@@ -40,12 +40,10 @@\n the \n-lazy\n+%F0%9F%85%B2\n  dog\n",
+		},
+	} {
+		s := PatchToTextUnit(patches, text1, test.u)
+		assert.Equal(t, test.want, s)
+
+		got, err := PatchFromTextUnit(s, text1, test.u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, patches, got)
+		applied, _ := dmp.Apply(got, text1)
+		assert.Equal(t, text2, applied)
+	}
+}
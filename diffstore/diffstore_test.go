@@ -0,0 +1,168 @@
+package diffstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// wikiRevs returns n revisions of a page, each a small edit of the one
+// before.
+func wikiRevs(n int) []string {
+	var lines []string
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf("Line %d of the page.", i))
+	}
+	var revs []string
+	for i := 0; i < n; i++ {
+		k := (i * 37) % len(lines)
+		lines[k] = fmt.Sprintf("Line %d, edited in revision %d.", k, i)
+		revs = append(revs, strings.Join(lines, "\n"))
+	}
+	return revs
+}
+
+func checkRevs(t *testing.T, s *Store, doc string, revs []string) {
+	t.Helper()
+	for i, want := range revs {
+		got, err := s.Get(doc, i+1)
+		if err != nil {
+			t.Fatalf("get revision %d: %s", i+1, err)
+		}
+		if got != want {
+			t.Fatalf("revision %d: got wrong text", i+1)
+		}
+	}
+}
+
+func testStore(t *testing.T, storage Storage) {
+	s := New(storage, &Options{SnapshotInterval: 10})
+	revs := wikiRevs(35)
+	for i, text := range revs {
+		rev, err := s.Put("wiki/home", text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rev != i+1 {
+			t.Fatalf("got revision %d, want %d", rev, i+1)
+		}
+	}
+	checkRevs(t, s, "wiki/home", revs)
+
+	for rev := 1; rev <= len(revs); rev++ {
+		r, err := storage.Load("wiki/home", rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := rev%10 == 1; r.Snapshot != want {
+			t.Errorf("revision %d: snapshot is %v", rev, r.Snapshot)
+		}
+	}
+
+	latest, text, err := s.Latest("wiki/home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != len(revs) || text != revs[len(revs)-1] {
+		t.Errorf("got latest %d", latest)
+	}
+	if _, err := s.Get("wiki/home", len(revs)+1); err != ErrNotFound {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+	if _, err := s.Get("wiki/other", 1); err != ErrNotFound {
+		t.Errorf("got %v, want ErrNotFound", err)
+	}
+
+	// Compact with a longer interval, and then a shorter one.
+	for _, interval := range []int{100, 3} {
+		s := New(storage, &Options{SnapshotInterval: interval})
+		if err := s.Compact("wiki/home"); err != nil {
+			t.Fatal(err)
+		}
+		checkRevs(t, s, "wiki/home", revs)
+		for rev := 1; rev <= len(revs); rev++ {
+			r, err := storage.Load("wiki/home", rev)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := (rev-1)%interval == 0
+			if r.Snapshot != want {
+				t.Errorf(
+					"interval %d, revision %d: snapshot is %v",
+					interval, rev, r.Snapshot,
+				)
+			}
+		}
+	}
+}
+
+func TestMemStorage(t *testing.T) {
+	testStore(t, NewMemStorage())
+}
+
+func TestFileStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diffstore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testStore(t, NewFileStorage(dir))
+
+	if _, err := NewFileStorage(dir).Load("..", 1); err == nil {
+		t.Error("got no error for document name ..")
+	}
+}
+
+func TestStoreSize(t *testing.T) {
+	storage := NewMemStorage()
+	s := New(storage, nil)
+	full := 0
+	for _, text := range wikiRevs(200) {
+		if _, err := s.Put("page", text); err != nil {
+			t.Fatal(err)
+		}
+		full += len(text)
+	}
+	if size := storage.Size(); size*10 > full {
+		t.Errorf("stored %d bytes for %d bytes of revisions", size, full)
+	}
+}
+
+func TestStoreSmallEdits(t *testing.T) {
+	s := New(NewMemStorage(), &Options{SnapshotInterval: 1})
+	revs := []string{"", "a", "", "héllo wörld", "hello world", ""}
+	for _, text := range revs {
+		if _, err := s.Put("doc", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkRevs(t, s, "doc", revs)
+	if err := New(s.storage, nil).Compact("doc"); err != nil {
+		t.Fatal(err)
+	}
+	checkRevs(t, s, "doc", revs)
+}
+
+func TestStoreLatin1(t *testing.T) {
+	// Latin-1 text is not valid UTF-8; it must come back byte for byte.
+	line := "caf\xe9 cr\xe8me br\xfbl\xe9e, " + strings.Repeat("x", 100)
+	revs := []string{
+		line + "\n" + line,
+		line + "\n" + strings.Replace(line, "\xe9", "\xe8", 1),
+		"\xff" + line + "\n" + strings.Replace(line, "\xe9", "\xe8", 1),
+	}
+	s := New(NewMemStorage(), nil)
+	for _, text := range revs {
+		if _, err := s.Put("doc", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkRevs(t, s, "doc", revs)
+	c := New(s.storage, &Options{SnapshotInterval: 2})
+	if err := c.Compact("doc"); err != nil {
+		t.Fatal(err)
+	}
+	checkRevs(t, s, "doc", revs)
+}
@@ -0,0 +1,13 @@
+// Package diffstore stores the revisions of documents as periodic full
+// snapshots and deltas between them, on top of package diffmp.
+//
+// Each revision is saved as a record. A record is either a snapshot of
+// the full text, or a delta in the format of diffmp.DiffToDelta from the
+// revision before it. A revision is rebuilt by applying the deltas with
+// diffmp.FromDelta, starting from the nearest snapshot before it. The
+// snapshot interval bounds the length of these delta chains, and Compact
+// rewrites the records of a document to follow the current interval.
+//
+// Records are kept in a Storage. MemStorage keeps them in memory, and
+// FileStorage keeps them in files under a directory.
+package diffstore
@@ -0,0 +1,126 @@
+package diffstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileStorage keeps records in files under a directory. Each document
+// has a directory named by its path-escaped name, and each record is a
+// JSON file named by its revision number.
+type FileStorage struct {
+	dir string
+}
+
+// NewFileStorage creates a storage under dir. The directory is created
+// when a record is saved.
+func NewFileStorage(dir string) *FileStorage {
+	return &FileStorage{dir: dir}
+}
+
+func (s *FileStorage) docDir(doc string) (string, error) {
+	name := url.PathEscape(doc)
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid document name %q", doc)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+func recordFile(rev int) string {
+	return strconv.Itoa(rev) + ".json"
+}
+
+// Load loads the record of a revision.
+func (s *FileStorage) Load(doc string, rev int) (*Record, error) {
+	dir, err := s.docDir(doc)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := ioutil.ReadFile(filepath.Join(dir, recordFile(rev)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	r := new(Record)
+	if err := json.Unmarshal(bs, r); err != nil {
+		return nil, fmt.Errorf("revision %d of %q: %s", rev, doc, err)
+	}
+	if r.Rev != rev {
+		return nil, fmt.Errorf(
+			"revision %d of %q: record has revision %d", rev, doc, r.Rev,
+		)
+	}
+	return r, nil
+}
+
+// Save saves a record. The file is written to a temporary file first,
+// and then renamed into place.
+func (s *FileStorage) Save(doc string, r *Record) error {
+	if r.Rev < 1 {
+		return fmt.Errorf("invalid revision %d", r.Rev)
+	}
+	dir, err := s.docDir(doc)
+	if err != nil {
+		return err
+	}
+	bs, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if _, err := f.Write(bs); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, recordFile(r.Rev)))
+}
+
+// Latest returns the largest revision number of a document.
+func (s *FileStorage) Latest(doc string) (int, error) {
+	dir, err := s.docDir(doc)
+	if err != nil {
+		return 0, err
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	latest := 0
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		rev, err := strconv.Atoi(strings.TrimSuffix(name, ".json"))
+		if err != nil || rev < 1 {
+			continue
+		}
+		if rev > latest {
+			latest = rev
+		}
+	}
+	return latest, nil
+}
@@ -0,0 +1,68 @@
+package diffstore
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MemStorage keeps records in memory. It is safe for concurrent use.
+type MemStorage struct {
+	mu   sync.Mutex
+	docs map[string][]*Record
+}
+
+// NewMemStorage creates an empty storage in memory.
+func NewMemStorage() *MemStorage {
+	return &MemStorage{docs: make(map[string][]*Record)}
+}
+
+// Load loads the record of a revision.
+func (s *MemStorage) Load(doc string, rev int) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rs := s.docs[doc]
+	if rev < 1 || rev > len(rs) || rs[rev-1] == nil {
+		return nil, ErrNotFound
+	}
+	r := *rs[rev-1]
+	return &r, nil
+}
+
+// Save saves a record.
+func (s *MemStorage) Save(doc string, r *Record) error {
+	if r.Rev < 1 {
+		return fmt.Errorf("invalid revision %d", r.Rev)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rs := s.docs[doc]
+	for len(rs) < r.Rev {
+		rs = append(rs, nil)
+	}
+	cp := *r
+	rs[r.Rev-1] = &cp
+	s.docs[doc] = rs
+	return nil
+}
+
+// Latest returns the largest revision number of a document.
+func (s *MemStorage) Latest(doc string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.docs[doc]), nil
+}
+
+// Size returns the total bytes of record data in the storage.
+func (s *MemStorage) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, rs := range s.docs {
+		for _, r := range rs {
+			if r != nil {
+				n += len(r.Data)
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,37 @@
+package diffstore
+
+import (
+	"errors"
+)
+
+// ErrNotFound is returned when a revision does not exist.
+var ErrNotFound = errors.New("diffstore: revision not found")
+
+// Record is a saved revision of a document.
+type Record struct {
+	// Rev is the revision number. Revisions of a document are numbered
+	// from 1.
+	Rev int `json:"rev"`
+
+	// Snapshot tells if Data is the full text. Otherwise Data is a delta
+	// from the text of revision Rev-1.
+	Snapshot bool `json:"snapshot,omitempty"`
+
+	// Data is the full text or the delta.
+	Data string `json:"data"`
+}
+
+// Storage keeps the records of documents.
+type Storage interface {
+	// Load loads the record of a revision. It returns ErrNotFound if the
+	// revision does not exist.
+	Load(doc string, rev int) (*Record, error)
+
+	// Save saves a record, and replaces the existing record of the same
+	// revision.
+	Save(doc string, r *Record) error
+
+	// Latest returns the largest revision number of a document, or 0 if
+	// the document has no revisions.
+	Latest(doc string) (int, error)
+}
@@ -0,0 +1,207 @@
+package diffstore
+
+import (
+	"fmt"
+	"sync"
+
+	"shanhu.io/third/diffmp"
+)
+
+// DefaultSnapshotInterval is the snapshot interval of a store when the
+// options do not set one.
+const DefaultSnapshotInterval = 32
+
+// Options configures a store.
+type Options struct {
+	// SnapshotInterval is how often a full snapshot is saved: a delta
+	// chain has less than SnapshotInterval deltas. 1 saves every
+	// revision in full. DefaultSnapshotInterval is used when it is 0.
+	SnapshotInterval int
+
+	// DMP is the diffmp configuration that deltas are computed with. The
+	// default configuration is used when it is nil.
+	DMP *diffmp.DMP
+}
+
+// Store keeps the revisions of documents in a storage. It is safe for
+// concurrent use when the storage is only used by the store.
+type Store struct {
+	storage  Storage
+	interval int
+	dmp      *diffmp.DMP
+
+	mu sync.Mutex
+}
+
+// New creates a store on a storage. Default options are used when opts
+// is nil.
+func New(s Storage, opts *Options) *Store {
+	if opts == nil {
+		opts = new(Options)
+	}
+	interval := opts.SnapshotInterval
+	if interval <= 0 {
+		interval = DefaultSnapshotInterval
+	}
+	dmp := opts.DMP
+	if dmp == nil {
+		dmp = diffmp.New()
+	}
+	return &Store{storage: s, interval: interval, dmp: dmp}
+}
+
+// record makes the record of revision rev that has text, where prev is
+// the text of the revision before, and depth is the length of the delta
+// chain if the record is a delta. A snapshot is made when the chain is
+// too long, when the delta is not smaller than the text, or when the
+// delta does not give back the text, as with text that is not valid
+// UTF-8.
+func (s *Store) record(rev int, prev, text string, depth int) *Record {
+	if rev > 1 && depth < s.interval {
+		diffs := s.dmp.DiffMain(prev, text, true)
+		diffs = s.dmp.DiffCleanupEfficiency(diffs)
+		delta := diffmp.DiffToDelta(diffs)
+		if len(delta) < len(text) && deltaGives(prev, delta, text) {
+			return &Record{Rev: rev, Data: delta}
+		}
+	}
+	return &Record{Rev: rev, Snapshot: true, Data: text}
+}
+
+// deltaGives checks that applying delta to prev gives text.
+func deltaGives(prev, delta, text string) bool {
+	diffs, err := diffmp.FromDelta(prev, delta)
+	return err == nil && diffmp.DiffText2(diffs) == text
+}
+
+// apply returns the text of record r, where prev is the text of the
+// revision before.
+func apply(doc string, r *Record, prev string) (string, error) {
+	if r.Snapshot {
+		return r.Data, nil
+	}
+	diffs, err := diffmp.FromDelta(prev, r.Data)
+	if err != nil {
+		return "", fmt.Errorf("revision %d of %q: %s", r.Rev, doc, err)
+	}
+	return diffmp.DiffText2(diffs), nil
+}
+
+// load rebuilds the text of a revision, and returns it with the length
+// of its delta chain.
+func (s *Store) load(doc string, rev int) (string, int, error) {
+	if rev < 1 {
+		return "", 0, ErrNotFound
+	}
+	var chain []*Record
+	for {
+		r, err := s.storage.Load(doc, rev)
+		if err != nil {
+			return "", 0, err
+		}
+		chain = append(chain, r)
+		if r.Snapshot {
+			break
+		}
+		if rev--; rev < 1 {
+			return "", 0, fmt.Errorf("%q has no snapshot", doc)
+		}
+	}
+
+	text := ""
+	for i := len(chain) - 1; i >= 0; i-- {
+		t, err := apply(doc, chain[i], text)
+		if err != nil {
+			return "", 0, err
+		}
+		text = t
+	}
+	return text, len(chain) - 1, nil
+}
+
+// Put saves text as a new revision of a document, and returns the new
+// revision number.
+func (s *Store) Put(doc, text string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest, err := s.storage.Latest(doc)
+	if err != nil {
+		return 0, err
+	}
+	prev, depth := "", 0
+	if latest > 0 {
+		prev, depth, err = s.load(doc, latest)
+		if err != nil {
+			return 0, err
+		}
+	}
+	r := s.record(latest+1, prev, text, depth+1)
+	if err := s.storage.Save(doc, r); err != nil {
+		return 0, err
+	}
+	return r.Rev, nil
+}
+
+// Get rebuilds the text of a revision of a document. It returns
+// ErrNotFound if the revision does not exist.
+func (s *Store) Get(doc string, rev int) (string, error) {
+	text, _, err := s.load(doc, rev)
+	return text, err
+}
+
+// Latest returns the latest revision number of a document and its text.
+// The revision number is 0 if the document has no revisions.
+func (s *Store) Latest(doc string) (int, string, error) {
+	latest, err := s.storage.Latest(doc)
+	if err != nil || latest == 0 {
+		return 0, "", err
+	}
+	text, err := s.Get(doc, latest)
+	if err != nil {
+		return 0, "", err
+	}
+	return latest, text, nil
+}
+
+// Compact rewrites the records of a document to follow the snapshot
+// interval of the store: snapshots that are not needed are replaced by
+// deltas, and delta chains that are too long are broken by snapshots.
+// This is useful after the snapshot interval changes.
+func (s *Store) Compact(doc string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest, err := s.storage.Latest(doc)
+	if err != nil {
+		return err
+	}
+	prev, depth := "", 0
+	for rev := 1; rev <= latest; rev++ {
+		r, err := s.storage.Load(doc, rev)
+		if err != nil {
+			return err
+		}
+		text, err := apply(doc, r, prev)
+		if err != nil {
+			return err
+		}
+
+		nr := r
+		if r.Snapshot || depth+1 >= s.interval {
+			nr = s.record(rev, prev, text, depth+1)
+		}
+		if *nr != *r {
+			if err := s.storage.Save(doc, nr); err != nil {
+				return err
+			}
+		}
+		if nr.Snapshot {
+			depth = 0
+		} else {
+			depth++
+		}
+		prev = text
+	}
+	return nil
+}
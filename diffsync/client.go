@@ -0,0 +1,61 @@
+package diffsync
+
+import (
+	"shanhu.io/third/diffmp"
+)
+
+// Transport sends a message from a client to a server, and returns the
+// reply.
+type Transport interface {
+	Sync(m *Message) (*Message, error)
+}
+
+// Client syncs a document with a server.
+type Client struct {
+	doc       string
+	id        string
+	peer      *Peer
+	transport Transport
+	reset     bool
+}
+
+// NewClient creates a client that syncs text as the document named doc,
+// under the unique client name id. It uses the default diffmp
+// configuration when dmp is nil.
+func NewClient(
+	doc, id string, text Document, t Transport, dmp *diffmp.DMP,
+) *Client {
+	return &Client{
+		doc:       doc,
+		id:        id,
+		peer:      NewPeer(text, dmp),
+		transport: t,
+	}
+}
+
+// Sync sends the local changes to the server, and merges the changes from
+// the server. When a message is lost, the next Sync recovers. If it
+// returns ErrOutOfSync, call Reset before the next Sync.
+func (c *Client) Sync() error {
+	m := c.peer.MakeMessage()
+	m.Doc = c.doc
+	m.Client = c.id
+	m.Reset = c.reset
+	reply, err := c.transport.Sync(m)
+	if err != nil {
+		return err
+	}
+	if err := c.peer.Receive(reply); err != nil {
+		return err
+	}
+	c.reset = false
+	return nil
+}
+
+// Reset drops the client's shadows. On the next successful Sync, the
+// local text is replaced with the server's text; local changes that are
+// not synced yet are lost.
+func (c *Client) Reset() {
+	c.peer.reset()
+	c.reset = true
+}
@@ -0,0 +1,153 @@
+package diffsync
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+var errLost = errors.New("message lost")
+
+// lossyTransport drops the next request or reply when asked.
+type lossyTransport struct {
+	t           Transport
+	dropRequest bool
+	dropReply   bool
+}
+
+func (l *lossyTransport) Sync(m *Message) (*Message, error) {
+	if l.dropRequest {
+		l.dropRequest = false
+		return nil, errLost
+	}
+	reply, err := l.t.Sync(m)
+	if err != nil {
+		return nil, err
+	}
+	if l.dropReply {
+		l.dropReply = false
+		return nil, errLost
+	}
+	return reply, nil
+}
+
+func mustSync(t *testing.T, clients ...*Client) {
+	t.Helper()
+	for _, c := range clients {
+		if err := c.Sync(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertText(t *testing.T, doc Document, want string) {
+	t.Helper()
+	if got := doc.Text(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSync(t *testing.T) {
+	s := NewServer(nil)
+	s.Doc("notes").SetText("The quick brown fox.")
+
+	textA := NewTextDoc("")
+	textB := NewTextDoc("")
+	a := NewClient("notes", "a", textA, NewLocalTransport(s), nil)
+	b := NewClient("notes", "b", textB, NewLocalTransport(s), nil)
+	mustSync(t, a, b)
+	assertText(t, textA, "The quick brown fox.")
+	assertText(t, textB, "The quick brown fox.")
+
+	// Concurrent edits on both clients merge.
+	textA.SetText("The quick red fox.")
+	textB.SetText("The quick brown fox jumps.")
+	mustSync(t, a, b, a)
+	want := "The quick red fox jumps."
+	assertText(t, s.Doc("notes"), want)
+	assertText(t, textA, want)
+	assertText(t, textB, want)
+}
+
+func TestSyncLostMessages(t *testing.T) {
+	s := NewServer(nil)
+	text := NewTextDoc("")
+	lossy := &lossyTransport{t: NewLocalTransport(s)}
+	c := NewClient("doc", "c", text, lossy, nil)
+
+	text.SetText("hello")
+	lossy.dropRequest = true
+	if err := c.Sync(); err != errLost {
+		t.Fatalf("got error %v, want %v", err, errLost)
+	}
+	text.SetText("hello world")
+	mustSync(t, c)
+	assertText(t, s.Doc("doc"), "hello world")
+
+	// The server applies the edit, but the reply is lost. The edit is
+	// sent again, and is applied only once.
+	text.SetText("hello, world")
+	lossy.dropReply = true
+	if err := c.Sync(); err != errLost {
+		t.Fatalf("got error %v, want %v", err, errLost)
+	}
+	mustSync(t, c)
+	assertText(t, s.Doc("doc"), "hello, world")
+
+	// A server change is lost on the way back; the backup shadow on the
+	// server recovers.
+	s.Doc("doc").SetText("hello, world!")
+	lossy.dropReply = true
+	c.Sync()
+	text.SetText("Hello, world")
+	mustSync(t, c, c)
+	assertText(t, s.Doc("doc"), "Hello, world!")
+	assertText(t, text, "Hello, world!")
+}
+
+func TestSyncReset(t *testing.T) {
+	s := NewServer(nil)
+	text := NewTextDoc("draft")
+	c := NewClient("doc", "c", text, NewLocalTransport(s), nil)
+	mustSync(t, c)
+	text.SetText("draft 2")
+	mustSync(t, c)
+
+	// The server restarts and forgets the client.
+	s = NewServer(nil)
+	s.Doc("doc").SetText("saved")
+	c.transport = NewLocalTransport(s)
+	if err := c.Sync(); err != ErrOutOfSync {
+		t.Fatalf("got error %v, want %v", err, ErrOutOfSync)
+	}
+	c.Reset()
+	mustSync(t, c)
+	assertText(t, text, "saved")
+
+	text.SetText("saved 2")
+	mustSync(t, c)
+	assertText(t, s.Doc("doc"), "saved 2")
+}
+
+func TestSyncHTTP(t *testing.T) {
+	s := NewServer(nil)
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	textA := NewTextDoc("alpha\n")
+	textB := NewTextDoc("")
+	ta := &HTTPTransport{URL: server.URL}
+	tb := &HTTPTransport{URL: server.URL}
+	a := NewClient("doc", "a", textA, ta, nil)
+	b := NewClient("doc", "b", textB, tb, nil)
+	mustSync(t, a, b)
+	assertText(t, textB, "alpha\n")
+
+	textB.SetText("alpha\nbeta\n")
+	mustSync(t, b, a)
+	assertText(t, textA, "alpha\nbeta\n")
+
+	if _, err := ta.Sync(&Message{Doc: "doc"}); err == nil {
+		t.Error("sync without a client name should fail")
+	}
+}
@@ -0,0 +1,17 @@
+// Package diffsync implements differential synchronization, as described
+// by Neil Fraser, on top of package diffmp.
+//
+// Each side of a connection keeps a shadow of the text as it last agreed
+// with the other side. Local changes are found by diffing the shadow with
+// the text, and are sent as deltas with version numbers. Received deltas
+// are applied strictly on the shadow, and fuzzily with patches on the
+// text, so that concurrent changes on both sides merge.
+//
+// This is the guaranteed delivery variant: unacknowledged edits are kept
+// in a stack and sent again, duplicate edits are ignored, and a backup
+// shadow recovers from lost replies. When the two sides can no longer
+// agree on versions, the client resets and takes the server's text.
+//
+// A Server serves many documents and many clients per document, with an
+// HTTP handler. Clients talk to it through a Transport.
+package diffsync
@@ -0,0 +1,37 @@
+package diffsync
+
+import (
+	"sync"
+)
+
+// Document is the text being synced. A server shares one document among
+// all clients that sync it.
+type Document interface {
+	Text() string
+	SetText(s string)
+}
+
+// TextDoc is a Document in memory. It is safe for concurrent use.
+type TextDoc struct {
+	mu   sync.Mutex
+	text string
+}
+
+// NewTextDoc creates a document with the given text.
+func NewTextDoc(text string) *TextDoc {
+	return &TextDoc{text: text}
+}
+
+// Text returns the text.
+func (d *TextDoc) Text() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.text
+}
+
+// SetText replaces the text.
+func (d *TextDoc) SetText(s string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.text = s
+}
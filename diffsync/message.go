@@ -0,0 +1,41 @@
+package diffsync
+
+import (
+	"errors"
+)
+
+// ErrOutOfSync is returned when the two sides of a connection disagree
+// on versions and can not recover. The client needs to reset.
+var ErrOutOfSync = errors.New("diffsync: out of sync")
+
+// Edit is a change of the sender's shadow.
+type Edit struct {
+	// Version is the sender's version of the shadow that the delta
+	// applies to.
+	Version int `json:"v"`
+
+	// Delta is the change in the format of diffmp.DiffToDelta.
+	Delta string `json:"d"`
+}
+
+// Message is what one side sends to the other in a sync.
+type Message struct {
+	// Doc is the name of the document, and Client is the unique name of
+	// the client. They are set by clients, and used by servers to find
+	// the shadow.
+	Doc    string `json:"doc,omitempty"`
+	Client string `json:"client,omitempty"`
+
+	// Reset asks the server to drop the client's shadow, and to send its
+	// full text.
+	Reset bool `json:"reset,omitempty"`
+
+	// Ack is the number of the receiver's edits that the sender has
+	// applied, which is the version of the receiver's shadow that the
+	// sender has.
+	Ack int `json:"ack"`
+
+	// Edits are the sender's edits that are not acknowledged yet, in
+	// order.
+	Edits []*Edit `json:"edits,omitempty"`
+}
@@ -0,0 +1,130 @@
+package diffsync
+
+import (
+	"shanhu.io/third/diffmp"
+)
+
+// shadow is the text as both sides last agreed on, with versions.
+type shadow struct {
+	text   string
+	local  int // number of local edits made on the shadow
+	remote int // number of remote edits applied on the shadow
+}
+
+// Peer is one side of a connection. It keeps the shadow, the backup
+// shadow and the stack of unacknowledged edits for the document, and is
+// the state machine of the protocol. A Peer is not safe for concurrent
+// use.
+type Peer struct {
+	doc    Document
+	dmp    *diffmp.DMP
+	shadow shadow
+	backup shadow
+	edits  []*Edit
+
+	// raw is set after a reset. The text that the other side sends
+	// replaces the document instead of being merged.
+	raw bool
+}
+
+// NewPeer creates a peer for a document, with an empty shadow. It uses
+// the default diffmp configuration when dmp is nil.
+func NewPeer(doc Document, dmp *diffmp.DMP) *Peer {
+	if dmp == nil {
+		dmp = diffmp.New()
+	}
+	return &Peer{doc: doc, dmp: dmp}
+}
+
+// MakeMessage diffs the document against the shadow, and if they differ,
+// pushes the change on the edit stack and updates the shadow. It returns
+// a message with all unacknowledged edits.
+func (p *Peer) MakeMessage() *Message {
+	text := p.doc.Text()
+	if !p.raw && text != p.shadow.text {
+		diffs := p.dmp.DiffMain(p.shadow.text, text, true)
+		if len(diffs) > 2 {
+			diffs = diffmp.DiffCleanupSemantic(diffs)
+			diffs = p.dmp.DiffCleanupEfficiency(diffs)
+		}
+		p.edits = append(p.edits, &Edit{
+			Version: p.shadow.local,
+			Delta:   diffmp.DiffToDelta(diffs),
+		})
+		p.shadow.local++
+		p.shadow.text = text
+	}
+
+	edits := make([]*Edit, len(p.edits))
+	copy(edits, p.edits)
+	return &Message{Ack: p.shadow.remote, Edits: edits}
+}
+
+// receiveAck handles the acknowledgement of local edits in a message.
+func (p *Peer) receiveAck(ack int) error {
+	switch ack {
+	case p.shadow.local:
+	case p.backup.local:
+		// The other side did not get the last message; go back to
+		// the shadow before it. The changes in the lost edits are
+		// still in the document, and are diffed again.
+		p.shadow = p.backup
+		p.edits = nil
+		return nil
+	default:
+		return ErrOutOfSync
+	}
+
+	i := 0
+	for i < len(p.edits) && p.edits[i].Version < ack {
+		i++
+	}
+	p.edits = p.edits[i:]
+	return nil
+}
+
+// Receive applies a message from the other side. Edits are applied on
+// the shadow strictly, and merged into the document with patches.
+// Edits that are already applied are ignored. It returns ErrOutOfSync if
+// the versions or the shadows of the two sides do not agree.
+func (p *Peer) Receive(m *Message) error {
+	if err := p.receiveAck(m.Ack); err != nil {
+		return err
+	}
+
+	for _, e := range m.Edits {
+		if e.Version < p.shadow.remote {
+			continue // Duplicate.
+		}
+		if e.Version > p.shadow.remote {
+			return ErrOutOfSync
+		}
+		diffs, err := diffmp.FromDelta(p.shadow.text, e.Delta)
+		if err != nil {
+			// The shadows are different.
+			return ErrOutOfSync
+		}
+		if !p.raw {
+			patches := p.dmp.PatchMake(p.shadow.text, diffs)
+			text, _ := p.dmp.Apply(patches, p.doc.Text())
+			p.doc.SetText(text)
+		}
+		p.shadow.text = diffmp.DiffText2(diffs)
+		p.shadow.remote++
+	}
+	if p.raw {
+		p.doc.SetText(p.shadow.text)
+		p.raw = false
+	}
+	p.backup = p.shadow
+	return nil
+}
+
+// reset drops the shadows and the edit stack, so that the text that the
+// other side sends next replaces the document.
+func (p *Peer) reset() {
+	p.shadow = shadow{}
+	p.backup = shadow{}
+	p.edits = nil
+	p.raw = true
+}
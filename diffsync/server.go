@@ -0,0 +1,78 @@
+package diffsync
+
+import (
+	"fmt"
+	"sync"
+
+	"shanhu.io/third/diffmp"
+)
+
+type serverDoc struct {
+	doc   Document
+	peers map[string]*Peer
+}
+
+// Server syncs documents with clients. It keeps a peer for each client
+// of each document. It is safe for concurrent use.
+type Server struct {
+	dmp *diffmp.DMP
+
+	mu   sync.Mutex
+	docs map[string]*serverDoc
+}
+
+// NewServer creates a server with no documents. It uses the default
+// diffmp configuration when dmp is nil.
+func NewServer(dmp *diffmp.DMP) *Server {
+	return &Server{
+		dmp:  dmp,
+		docs: make(map[string]*serverDoc),
+	}
+}
+
+func (s *Server) docLocked(name string) *serverDoc {
+	d, ok := s.docs[name]
+	if !ok {
+		d = &serverDoc{
+			doc:   NewTextDoc(""),
+			peers: make(map[string]*Peer),
+		}
+		s.docs[name] = d
+	}
+	return d
+}
+
+// Doc returns the document with the given name. A document is created
+// empty when it is first used.
+func (s *Server) Doc(name string) Document {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.docLocked(name).doc
+}
+
+// Sync handles a message from a client, and returns the reply. A client
+// that the server does not know starts with an empty shadow. When it
+// returns ErrOutOfSync, the server forgets the client, which needs to
+// reset.
+func (s *Server) Sync(m *Message) (*Message, error) {
+	if m.Doc == "" || m.Client == "" {
+		return nil, fmt.Errorf("message has no document or client name")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.docLocked(m.Doc)
+	p, ok := d.peers[m.Client]
+	if !ok || m.Reset {
+		p = NewPeer(d.doc, s.dmp)
+		d.peers[m.Client] = p
+	}
+	if err := p.Receive(m); err != nil {
+		if err == ErrOutOfSync {
+			delete(d.peers, m.Client)
+		}
+		return nil, err
+	}
+	return p.MakeMessage(), nil
+}
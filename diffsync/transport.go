@@ -0,0 +1,109 @@
+package diffsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// copyMessage copies a message through JSON, like a transport over the
+// wire does.
+func copyMessage(m *Message) (*Message, error) {
+	bs, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	ret := new(Message)
+	if err := json.Unmarshal(bs, ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+type localTransport struct {
+	server *Server
+}
+
+// NewLocalTransport returns a transport that sends messages to a server
+// in the same process. Messages are copied as if they are sent over the
+// wire.
+func NewLocalTransport(s *Server) Transport {
+	return &localTransport{server: s}
+}
+
+func (t *localTransport) Sync(m *Message) (*Message, error) {
+	req, err := copyMessage(m)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := t.server.Sync(req)
+	if err != nil {
+		return nil, err
+	}
+	return copyMessage(reply)
+}
+
+// ServeHTTP serves syncs over HTTP. The request is a POST with a JSON
+// message, and the reply is a JSON message. It replies with status 409
+// Conflict for ErrOutOfSync.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m := new(Message)
+	if err := json.NewDecoder(req.Body).Decode(m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reply, err := s.Sync(m)
+	if err == ErrOutOfSync {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reply)
+}
+
+// HTTPTransport sends messages to a server over HTTP.
+type HTTPTransport struct {
+	URL    string
+	Client *http.Client // http.DefaultClient if nil
+}
+
+// Sync posts the message to the server, and returns the reply.
+func (t *HTTPTransport) Sync(m *Message) (*Message, error) {
+	bs, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(t.URL, "application/json", bytes.NewReader(bs))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return nil, ErrOutOfSync
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf(
+			"diffsync: %s: %s", resp.Status, bytes.TrimSpace(body),
+		)
+	}
+	reply := new(Message)
+	if err := json.NewDecoder(resp.Body).Decode(reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}